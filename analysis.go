@@ -1,5 +1,7 @@
 package collatinus
 
+import "strconv"
+
 // PartOfSpeech represents the grammatical category of a lemma.
 type PartOfSpeech rune
 
@@ -17,6 +19,72 @@ const (
 	POSUnknown      PartOfSpeech = '-'
 )
 
+// posCodes are the valid part-of-speech codes of the seventh field of
+// lemmes.la.
+var posCodes = []PartOfSpeech{
+	POSNoun, POSVerb, POSAdjective, POSPronoun, POSAdverb, POSConjunction,
+	POSExclamation, POSInterjection, POSNumeral, POSPreposition,
+}
+
+// POSSource tells where the part of speech of a lemma comes from.
+type POSSource int
+
+const (
+	// POSFromNone: the part of speech is unknown.
+	POSFromNone POSSource = iota
+	// POSFromData: the seventh field of the lemmes.la line gives it.
+	POSFromData
+	// POSFromHeuristic: it is guessed from the morphological information
+	// ("adj.", "conj."…) or from the table of numerals.
+	POSFromHeuristic
+	// POSFromModel: it is that of the inflection model.
+	POSFromModel
+)
+
+// String returns the name of the source: "none", "data", "heuristic" or
+// "model".
+func (s POSSource) String() string {
+	switch s {
+	case POSFromData:
+		return "data"
+	case POSFromHeuristic:
+		return "heuristic"
+	case POSFromModel:
+		return "model"
+	default:
+		return "none"
+	}
+}
+
+// Register is the chronological or stylistic register of a lemma, as
+// marked in its morphological information. The bundled lexicon only marks
+// archaic words ("arch."); unmarked lemmas are classical.
+type Register int
+
+const (
+	RegisterClassical Register = iota
+	RegisterArchaic
+	RegisterPoetic
+	RegisterLate
+	RegisterMedieval
+)
+
+// String returns the English name of the register.
+func (r Register) String() string {
+	switch r {
+	case RegisterArchaic:
+		return "archaic"
+	case RegisterPoetic:
+		return "poetic"
+	case RegisterLate:
+		return "late"
+	case RegisterMedieval:
+		return "medieval"
+	default:
+		return "classical"
+	}
+}
+
 // Analysis holds a single morphological analysis for a word form.
 type Analysis struct {
 	// FormWithMarks is the form with vowel quantity marks (radical + desinence grq).
@@ -24,8 +92,71 @@ type Analysis struct {
 	// MorphoDescription is the human-readable morphological description,
 	// e.g. "nominatif singulier".
 	MorphoDescription string
+	// Morphology holds the features of MorphoDescription.
+	Morphology Morphology
 	// MorphoIndex is the 1-based index into the morphos list.
 	MorphoIndex int
+	// Prefix is the verbal prefix stripped by the compound-verb fallback
+	// (e.g. "per" for pertranseo → transeo); empty otherwise.
+	Prefix string
+	// Enclitic is the enclitic stripped from the form, "que" for
+	// populusque read as populus, or "cum" for the pronoun of mecum; "st"
+	// stands for est (amatust). Empty otherwise.
+	Enclitic string
+	// StemLength is the number of letters of the form covered by the
+	// radical, prefix included; an irregular form is all stem.
+	StemLength int
+	// Attested is true when the form comes from the irregular forms of the
+	// data rather than from a radical and a desinence of the model.
+	Attested bool
+	// Desinence is the ending of the model that produced the analysis,
+	// with its radical and morpho numbers, for tracing it to modeles.la.
+	// It is only set with LemmatizeOptions.Verbose, and never for an
+	// attested form.
+	Desinence *Desinence
+	// Derivation records the rewritings of the form the analysis needed,
+	// zero when it reads the form as it stands.
+	Derivation Source
+}
+
+// Source is a set of rewritings of a form, which LemmatizeWord tries when
+// the form as it stands may have more analyses or none.
+type Source uint8
+
+const (
+	// SourceContraction expands a contracted perfect: amasti → amauisti.
+	SourceContraction Source = 1 << iota
+	// SourceAssimilation assimilates or restores the last letter of a
+	// prefix: adfero ↔ affero.
+	SourceAssimilation
+	// SourceEnclitic strips an enclitic: -que, -ne, -ue, -ve, -st, or cum
+	// after a pronoun.
+	SourceEnclitic
+	// SourceGreekSpelling respells a Greek loanword; see
+	// LemmatizeOptions.GreekSpellings.
+	SourceGreekSpelling
+	// SourcePrefix strips a verbal prefix; see LemmatizeOptions.Prefixes.
+	SourcePrefix
+)
+
+// Key identifies the analysis by morpho index and marked form, e.g.
+// "4:pŭēllāe", for set operations on analyses.
+func (a Analysis) Key() string {
+	return strconv.Itoa(a.MorphoIndex) + ":" + a.FormWithMarks
+}
+
+// DedupeAnalyses returns the analyses of as with distinct keys, keeping
+// the first one of each key. It reuses the storage of as.
+func DedupeAnalyses(as []Analysis) []Analysis {
+	seen := make(map[string]bool, len(as))
+	out := as[:0]
+	for _, a := range as {
+		if k := a.Key(); !seen[k] {
+			seen[k] = true
+			out = append(out, a)
+		}
+	}
+	return out
 }
 
 // LemmatizationResult holds the lemmatization result for a single token.
@@ -34,6 +165,13 @@ type LemmatizationResult struct {
 	Token string
 	// Analyses maps each matching Lemma to its list of analyses.
 	Analyses map[*Lemma][]Analysis
+	// Start and End are the byte offsets of Token in the text, once
+	// composed to NFC (the text itself when it already is). A word
+	// rejoined across a line break spans both of its halves.
+	Start, End int
+	// SentenceStart is true for a word that starts a sentence, as
+	// Tokenize finds it.
+	SentenceStart bool
 }
 
 // InflectionTable holds the full inflection table for a lemma.
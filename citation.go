@@ -0,0 +1,179 @@
+package collatinus
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// CitationStyle selects how LexiconCitation cites a lemma.
+type CitationStyle int
+
+const (
+	// CitationAbbreviated cites the forms after the headword by their
+	// endings, the verb's infinitive last, as Lewis & Short do:
+	// "amō, āvī, ātum, āre", "rosa, ae, f.", "bonus, a, um".
+	CitationAbbreviated CitationStyle = iota
+	// CitationFull cites them in full, the infinitive second, as the
+	// Oxford Latin Dictionary does: "amō, amāre, amāvī, amātum".
+	CitationFull
+)
+
+// Morpho indices of the principal parts.
+const (
+	morphoNomSg     = 1
+	morphoGenSg     = 4
+	morphoNomPl     = 7
+	morphoGenPl     = 10
+	morphoMascNom   = 13
+	morphoMascGen   = 16
+	morphoFemNom    = 25
+	morphoNeutNom   = 37
+	morphoPres1Sg   = 121
+	morphoPerf1Sg   = 139
+	morphoInfPres   = 187
+	morphoSupine    = 265
+	morphoPerfPartA = 375
+)
+
+// lexiconCitation implements LexiconCitation.
+func (l *Lemmatizer) lexiconCitation(lemma *Lemma) string {
+	table := l.inflectionTable(lemma)
+	cell := func(mn int) string {
+		if table == nil || len(table.Cells[mn]) == 0 {
+			return ""
+		}
+		return table.Cells[mn][0]
+	}
+
+	var head, inf string
+	var parts, tail []string
+	switch lemma.POS {
+	case POSVerb:
+		// The models of deponents put their forms in the active cells.
+		head, inf = cell(morphoPres1Sg), cell(morphoInfPres)
+		if !lemma.Deponent {
+			parts = append(parts, cell(morphoPerf1Sg), cell(morphoSupine))
+		} else if perf := cell(morphoPerfPartA); perf != "" {
+			parts = append(parts, perf+" sum")
+		}
+	case POSNoun:
+		head = cell(morphoNomSg)
+		gen := cell(morphoGenSg)
+		if head == "" {
+			head, gen = cell(morphoNomPl), cell(morphoGenPl)
+		}
+		parts = append(parts, gen)
+		if genders := nounGenders(lemma); len(genders) > 0 {
+			tail = append(tail, strings.Join(genders, "/"))
+		}
+	case POSAdjective:
+		head = cell(morphoMascNom)
+		fem, neut, gen := cell(morphoFemNom), cell(morphoNeutNom), cell(morphoMascGen)
+		switch {
+		case fem != head:
+			parts = append(parts, fem, neut)
+		case gen == head:
+			// fortis, e
+			parts = append(parts, neut)
+		default:
+			// ingens, entis
+			parts = append(parts, gen)
+		}
+	}
+	if head == "" {
+		return dictionaryMarks(lemma.Grq)
+	}
+
+	if l.citationStyle == CitationFull {
+		parts = append([]string{inf}, parts...)
+	} else {
+		parts = append(parts, inf)
+	}
+	head = dictionaryMarks(head)
+	cited := []string{head}
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		part = dictionaryMarks(part)
+		if l.citationStyle == CitationAbbreviated {
+			part = citationEnding(head, part)
+		}
+		cited = append(cited, part)
+	}
+	return strings.Join(append(cited, tail...), ", ")
+}
+
+// dictionaryMarks marks form as dictionaries do: the long vowels only,
+// and not the diphthongs, which Collatinus marks long on their first
+// vowel.
+func dictionaryMarks(form string) string {
+	ls := letters(form)
+	var b strings.Builder
+	for i, letter := range ls {
+		if strings.ContainsAny(letter, "ăĕĭŏŭ") {
+			letter = Atone(letter)
+		} else if i+1 < len(ls) && strings.Contains("āe āu ēu ōe", strings.ToLower(letter+ls[i+1])) {
+			letter = Atone(letter)
+		}
+		b.WriteString(strings.ReplaceAll(letter, "\u0306", ""))
+	}
+	return b.String()
+}
+
+// citationEnding returns the ending of form after the stem it shares with
+// head, cut before the last vowels of head, and before a vowel following
+// a consonant: "āvī" of "amāvī" after "amō", "eris" of "celeris" after
+// "celer". It returns the whole form when that leaves less than two
+// letters of the stem. The quantity marks are ignored in comparing.
+func citationEnding(head, form string) string {
+	hl, fl := letters(head), letters(form)
+	vowel := func(l string) bool { return strings.ContainsAny(Atone(l), "aeiouy") }
+	n := 0
+	for n < len(hl) && n < len(fl) && Atone(hl[n]) == Atone(fl[n]) {
+		n++
+	}
+	last := len(hl) - 1
+	for last >= 0 && !vowel(hl[last]) {
+		last--
+	}
+	for last > 0 && vowel(hl[last-1]) {
+		last--
+	}
+	if last >= 0 {
+		n = min(n, last)
+	}
+	for n > 0 && (n == len(fl) || vowel(fl[n-1]) || !vowel(fl[n])) {
+		n--
+	}
+	if n < 2 {
+		return form
+	}
+	return strings.Join(fl[n:], "")
+}
+
+// letters splits s into its letters, each with its combining marks.
+func letters(s string) []string {
+	var ls []string
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) && len(ls) > 0 {
+			ls[len(ls)-1] += string(r)
+		} else {
+			ls = append(ls, string(r))
+		}
+	}
+	return ls
+}
+
+// nounGenders returns the genders the IndMorph of the noun lemma gives,
+// as abbreviated there: "m.", "f." or "n.".
+func nounGenders(lemma *Lemma) []string {
+	var genders []string
+	for _, g := range []string{"m.", "f.", "n."} {
+		if slices.Contains(strings.Fields(strings.ReplaceAll(lemma.IndMorph, ",", " ")), g) {
+			genders = append(genders, g)
+		}
+	}
+	return genders
+}
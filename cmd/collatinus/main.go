@@ -0,0 +1,129 @@
+// Command collatinus lemmatizes Latin words and prints inflection tables
+// from the terminal.
+//
+// Usage:
+//
+//	collatinus [-data dir] word...         lemmatize the words
+//	collatinus [-data dir] -inflect lupus  print the inflection table of a lemma
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	collatinus "github.com/cours-de-latin/collatinus"
+)
+
+// numbers are the columns of an inflection table.
+var numbers = []string{"singulier", "pluriel"}
+
+// printInflection prints the inflection table of lemma with one row per
+// morpho description stripped of its number, and the singular and plural
+// forms as columns. Cells without a number (infinitives, supines, …) go
+// to the first column. A lemma without a model (bene) is printed as not
+// inflected.
+func printInflection(w io.Writer, lem *collatinus.Lemmatizer, lemma *collatinus.Lemma) {
+	table := lem.InflectionTable(lemma)
+	if table == nil {
+		fmt.Fprintf(w, "%s, %s\n  (not inflected)\n", lemma.Grq, lemma.IndMorph)
+		return
+	}
+	mns := make([]int, 0, len(table.Cells))
+	for mn := range table.Cells {
+		mns = append(mns, mn)
+	}
+	sort.Ints(mns)
+
+	var rows []string
+	cells := make(map[string][]string)
+	for _, mn := range mns {
+		col := 0
+		var words []string
+		for _, word := range strings.Fields(lem.MorphoOf(lemma, mn)) {
+			if word == numbers[1] {
+				col = 1
+			} else if word != numbers[0] {
+				words = append(words, word)
+			}
+		}
+		row := strings.Join(words, " ")
+		if _, ok := cells[row]; !ok {
+			rows = append(rows, row)
+			cells[row] = make([]string, len(numbers))
+		}
+		cells[row][col] = strings.Join(table.Cells[mn], ", ")
+	}
+
+	fmt.Fprintf(w, "%s, %s\n\n", lemma.Grq, lemma.IndMorph)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\t%s\n", strings.Join(numbers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\n", row, strings.Join(cells[row], "\t"))
+	}
+	tw.Flush()
+}
+
+// printAnalyses prints the lemmas and analyses of form.
+func printAnalyses(w io.Writer, lem *collatinus.Lemmatizer, form string) {
+	analyses := lem.LemmatizeWord(form, false)
+	lemmas := make([]*collatinus.Lemma, 0, len(analyses))
+	for lemma := range analyses {
+		lemmas = append(lemmas, lemma)
+	}
+	sort.Slice(lemmas, func(i, j int) bool { return lemmas[i].Key < lemmas[j].Key })
+
+	fmt.Fprintln(w, form)
+	if len(lemmas) == 0 {
+		fmt.Fprintln(w, "  (unknown)")
+	}
+	for _, lemma := range lemmas {
+		fmt.Fprintf(w, "  %s, %s\n", lemma.Grq, lemma.IndMorph)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, a := range analyses[lemma] {
+			fmt.Fprintf(tw, "    %s\t%s\n", a.FormWithMarks, a.MorphoDescription)
+		}
+		tw.Flush()
+	}
+}
+
+func main() {
+	dataDir := flag.String("data", "data", "path to Collatinus data directory")
+	inflect := flag.String("inflect", "", "print the inflection table of this lemma")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-data dir] [-inflect lemma | word...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *inflect == "" && flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	lem, err := collatinus.New(*dataDir)
+	if err != nil {
+		log.Fatalf("failed to load data: %v", err)
+	}
+
+	if *inflect != "" {
+		lemmas := lem.FindLemma(*inflect)
+		if len(lemmas) == 0 {
+			log.Fatalf("lemma %q not found", *inflect)
+		}
+		for i, lemma := range lemmas {
+			if i > 0 {
+				fmt.Println()
+			}
+			printInflection(os.Stdout, lem, lemma)
+		}
+		return
+	}
+	for _, form := range flag.Args() {
+		printAnalyses(os.Stdout, lem, form)
+	}
+}
@@ -2,18 +2,73 @@
 //
 // Endpoints:
 //
-//	GET  /api/lemmatize?form=<word>[&sentence_start=true]
-//	POST /api/lemmatize/text   body: {"text":"..."}
-//	GET  /api/inflection?lemma=<key>
+//	GET  /api/lemmatize?form=<word>[&sentence_start=true][&marks=false][&lang=fr][&translation=false]
+//	POST /api/lemmatize/text[?format=spacy][&marks=false][&lang=fr][&translation=false]   body: {"text":"..."}
+//	GET  /api/lemmatize/incremental?prefix=<letters>[&limit=20][&lang=fr][&translation=false]
+//	POST /api/lemmatize/batch[?marks=false][&lang=fr][&translation=false]   body: {"forms":["amat","rosa"],"sentence_start":false}
+//	GET  /api/search?prefix=<letters>[&limit=20]
+//	GET  /api/inflection?lemma=<key>[&marks=false]
+//	GET  /api/inflection/query?lemma=<key>&feats=<UD features>[&marks=false]
+//	POST /api/inflection/batch[?marks=false]   body: {"lemmas":["amo","lupus"]}
+//	GET  /api/forms?lemma=<key>
+//	GET  /api/forms/match?pattern=<p??lla>[&limit=100][&marks=false]
+//	GET  /api/lemmas?model=<name>[&derived=true][&limit=n]
+//	POST /api/scan[?elide_m=false][&marks=false]   body: {"line":"..."}
+//	GET  /api/translation?lemma=<key>[&lang=fr]
+//	GET  /api/lemma?key=<key>
 //	GET  /api/languages
+//	GET  /api/version
+//	GET  /ws/lemmatize[?lang=fr][&translation=false]   WebSocket: one word per text message
+//
+// The analyses of /api/lemmatize, its text, incremental and batch
+// variants and /ws/lemmatize carry the translation of their lemma in the
+// language of lang, one of /api/languages; the default, and the fallback
+// for a language not loaded, is fr. Each analysis tells in
+// translation_lang the language of its translation, fr for a lemma
+// without a gloss in lang; translation=false leaves the translations out.
+//
+// /api/lemmatize/batch lemmatizes up to 1000 forms in one request, of a
+// body of at most 1 MiB, and returns their results in order; unlike
+// /api/lemmatize, a form without analyses does not make the response a
+// 404.
+//
+// /api/inflection/batch gives the tables of up to 500 lemmas, of a body
+// of at most 1 MiB, too.
+//
+// The forms of /api/lemmatize (with its batch), /api/lemmatize/text,
+// /api/inflection (with its query and batch), /api/forms/match and the
+// syllables of /api/scan carry vowel-quantity marks unless marks=false
+// (the default is marks=true), which strips them for clients that cannot
+// render them.
+//
+// /api/forms/match is served with -forms-match only, which builds the
+// form index it searches (as -form-index does) at startup.
+//
+// /api/scan fits the line to a dactylic hexameter, eliding a final -m
+// before a vowel unless elide_m=false, and breaks the feet down into
+// syllables, with the elisions and the main caesura.
+//
+// The tables of /api/inflection and its batch give their cells twice:
+// cells maps each morpho index, as a string, to its forms, and cell_list
+// lists them in the order of the indices, with their descriptions, for
+// rendering. The tables are cached by the lemmatizer once computed;
+// -precompute-inflections computes them all at startup, at the cost of
+// about 165 MB.
+//
+// Every JSON response is indented with pretty=true, for reading it by
+// hand; it is compact by default.
 package main
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,6 +77,10 @@ import (
 	"github.com/rs/cors"
 )
 
+// Version is the server version, set at build time with
+// -ldflags "-X main.Version=…".
+var Version = "dev"
+
 // ---- JSON response types ------------------------------------------------
 
 type lemmaJSON struct {
@@ -36,36 +95,183 @@ type formJSON struct {
 	FormWithMarks     string `json:"form_with_marks"`
 	MorphoDescription string `json:"morpho_description"`
 	MorphoIndex       int    `json:"morpho_index"`
+	// Attested is set for the irregular forms of the data, as opposed to
+	// the forms the model predicts.
+	Attested bool `json:"attested"`
 }
 
 type analysisJSON struct {
-	Lemma  lemmaJSON  `json:"lemma"`
-	Forms  []formJSON `json:"forms"`
+	Lemma lemmaJSON `json:"lemma"`
+	// Translation is the gloss of the lemma in TranslationLang: the
+	// requested language when the lemma has a gloss in it, else fr.
+	// Both are omitted when the lemma has neither, or with
+	// translation=false.
+	Translation     string     `json:"translation,omitempty"`
+	TranslationLang string     `json:"translation_lang,omitempty"`
+	Forms           []formJSON `json:"forms"`
 }
 
 type lemmatizeWordResponse struct {
-	Form     string         `json:"form"`
+	Form string `json:"form"`
+	// Lang is the requested language of the translations, omitted with
+	// translation=false; see analysisJSON.TranslationLang.
+	Lang     string         `json:"lang,omitempty"`
 	Analyses []analysisJSON `json:"analyses"`
 }
 
+type lemmatizeBatchResponse struct {
+	Lang string `json:"lang,omitempty"`
+	// Results holds one entry per requested form, in order; a form
+	// without analyses has an empty list.
+	Results []lemmatizeWordResponse `json:"results"`
+}
+
 type tokenResultJSON struct {
 	Token    string         `json:"token"`
 	Analyses []analysisJSON `json:"analyses"`
 }
 
 type lemmatizeTextResponse struct {
+	Lang    string            `json:"lang,omitempty"`
 	Results []tokenResultJSON `json:"results"`
 }
 
+type incrementalResponse struct {
+	Prefix   string         `json:"prefix"`
+	Lang     string         `json:"lang,omitempty"`
+	Lemmas   []lemmaJSON    `json:"lemmas"`
+	Analyses []analysisJSON `json:"analyses"`
+}
+
 type inflectionResponse struct {
-	Lemma *lemmaJSON         `json:"lemma"`
+	Lemma *lemmaJSON          `json:"lemma"`
 	Cells map[string][]string `json:"cells"`
+	// CellList holds Cells in the order of the morpho indices, with
+	// their descriptions.
+	CellList []cellJSON `json:"cell_list"`
+}
+
+type cellJSON struct {
+	MorphoIndex       int      `json:"morpho_index"`
+	MorphoDescription string   `json:"morpho_description"`
+	Forms             []string `json:"forms"`
+}
+
+type inflectionBatchResponse struct {
+	// Tables maps each requested key to its table, or to an error for
+	// unknown lemmas.
+	Tables map[string]inflectionBatchEntry `json:"tables"`
+}
+
+type inflectionBatchEntry struct {
+	Lemma    *lemmaJSON          `json:"lemma,omitempty"`
+	Cells    map[string][]string `json:"cells,omitempty"`
+	CellList []cellJSON          `json:"cell_list,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+type featureCellJSON struct {
+	MorphoIndex       int      `json:"morpho_index"`
+	MorphoDescription string   `json:"morpho_description"`
+	Feats             string   `json:"feats"`
+	Forms             []string `json:"forms"`
+}
+
+type inflectionQueryResponse struct {
+	Lemma *lemmaJSON        `json:"lemma"`
+	Feats string            `json:"feats"`
+	Cells []featureCellJSON `json:"cells"`
+}
+
+type formOfJSON struct {
+	Form          string `json:"form"`
+	FormWithMarks string `json:"form_with_marks"`
+	MorphoIndices []int  `json:"morpho_indices"`
+}
+
+type formsResponse struct {
+	Lemma      *lemmaJSON   `json:"lemma"`
+	Forms      []formOfJSON `json:"forms"`
+	PlainForms []string     `json:"plain_forms"`
+}
+
+type formMatchJSON struct {
+	Form              string    `json:"form"`
+	Lemma             lemmaJSON `json:"lemma"`
+	MorphoIndex       int       `json:"morpho_index"`
+	MorphoDescription string    `json:"morpho_description"`
+}
+
+type formsMatchResponse struct {
+	Pattern string          `json:"pattern"`
+	Matches []formMatchJSON `json:"matches"`
+	// Truncated is set when limit cut the matches short.
+	Truncated bool `json:"truncated"`
+}
+
+type searchResponse struct {
+	Prefix string      `json:"prefix"`
+	Lemmas []lemmaJSON `json:"lemmas"`
+}
+
+type lemmasResponse struct {
+	Model  string      `json:"model"`
+	Lemmas []lemmaJSON `json:"lemmas"`
+}
+
+type translationResponse struct {
+	Lemma string `json:"lemma"`
+	// Lang is the language of Translation: lang when the lemma has a
+	// translation in it, else fr.
+	Lang        string `json:"lang"`
+	Translation string `json:"translation"`
+	// Languages lists the languages the lemma has a translation in.
+	Languages []string `json:"languages"`
+}
+
+type lemmaResponse struct {
+	Lemma lemmaJSON `json:"lemma"`
+	Model string    `json:"model"`
+	NbOcc int       `json:"nb_occ"`
+	// References are the keys of the lemmas the "cf." of the entry
+	// points to.
+	References []string `json:"references"`
+	Radicals   []int    `json:"radicals"`
+	// Translations maps each language the lemma has a translation in to
+	// it.
+	Translations map[string]string `json:"translations"`
 }
 
 type languagesResponse struct {
 	Languages map[string]string `json:"languages"`
 }
 
+type versionResponse struct {
+	Version         string `json:"version"`
+	DataFingerprint string `json:"data_fingerprint"`
+}
+
+type syllableJSON struct {
+	Text    string `json:"text"`
+	Long    bool   `json:"long"`
+	WordEnd bool   `json:"word_end"`
+}
+
+type footJSON struct {
+	Kind      string         `json:"kind"`
+	Syllables []syllableJSON `json:"syllables"`
+}
+
+type scanResponse struct {
+	Line  string `json:"line"`
+	Valid bool   `json:"valid"`
+	// Feet is empty unless the line is a valid hexameter.
+	Feet []footJSON `json:"feet"`
+	// Elisions lists the syllables holding an elision, as in Feet.
+	Elisions []string `json:"elisions"`
+	Caesura  string   `json:"caesura,omitempty"`
+}
+
 type errorResponse struct {
 	Error string `json:"error"`
 }
@@ -109,23 +315,42 @@ func toLemmaJSON(l *collatinus.Lemma) lemmaJSON {
 	}
 }
 
-func toAnalysesJSON(analyses map[*collatinus.Lemma][]collatinus.Analysis) []analysisJSON {
+// toAnalysesJSON converts analyses, with the translations of their lemmas
+// in lang, stripping the quantity marks of the forms unless marks is true.
+func toAnalysesJSON(analyses map[*collatinus.Lemma][]collatinus.Analysis, marks bool, lang string) []analysisJSON {
 	out := make([]analysisJSON, 0, len(analyses))
 	for lemma, forms := range analyses {
 		fj := make([]formJSON, 0, len(forms))
+		seen := make(map[formJSON]int)
 		for _, f := range forms {
-			fj = append(fj, formJSON{
-				FormWithMarks:     f.FormWithMarks,
+			form := f.FormWithMarks
+			if !marks {
+				form = collatinus.Atone(form)
+			}
+			j := formJSON{
+				FormWithMarks:     form,
 				MorphoDescription: f.MorphoDescription,
 				MorphoIndex:       f.MorphoIndex,
-			})
+			}
+			// forms differing only by their marks collapse without them,
+			// attested if either is
+			if i, ok := seen[j]; ok {
+				fj[i].Attested = fj[i].Attested || f.Attested
+				continue
+			}
+			seen[j] = len(fj)
+			j.Attested = f.Attested
+			fj = append(fj, j)
 		}
 		// sort forms by morpho index for deterministic output
 		sort.Slice(fj, func(i, j int) bool {
 			return fj[i].MorphoIndex < fj[j].MorphoIndex
 		})
-		lj := toLemmaJSON(lemma)
-		out = append(out, analysisJSON{Lemma: lj, Forms: fj})
+		aj := analysisJSON{Lemma: toLemmaJSON(lemma), Forms: fj}
+		if lang != "" {
+			aj.Translation, aj.TranslationLang = translationOf(lemma, lang)
+		}
+		out = append(out, aj)
 	}
 	// sort by lemma key for deterministic output
 	sort.Slice(out, func(i, j int) bool {
@@ -134,16 +359,74 @@ func toAnalysesJSON(analyses map[*collatinus.Lemma][]collatinus.Analysis) []anal
 	return out
 }
 
-func writeJSON(w http.ResponseWriter, status int, v any) {
+// parseMarks reads the optional boolean 'marks' query parameter, true
+// when absent.
+func parseMarks(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("marks")
+	if v == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// parseLang reads the optional 'lang' query parameter, falling back to
+// fr when it is absent or names a language the data lacks. It returns ""
+// with translation=false, for analyses without translations.
+func parseLang(lem *collatinus.Lemmatizer, r *http.Request) string {
+	if on, err := strconv.ParseBool(r.URL.Query().Get("translation")); err == nil && !on {
+		return ""
+	}
+	lang := r.URL.Query().Get("lang")
+	if _, ok := lem.Languages()[lang]; !ok {
+		return "fr"
+	}
+	return lang
+}
+
+// translationOf returns the gloss of lemma in lang and the language it
+// is in: lang when the lemma has a gloss in it, else fr, the fallback of
+// Lemma.Translation, else none.
+func translationOf(lemma *collatinus.Lemma, lang string) (text, effective string) {
+	langs := lemma.TranslationLanguages()
+	switch {
+	case slices.Contains(langs, lang):
+		effective = lang
+	case slices.Contains(langs, "fr"):
+		effective = "fr"
+	default:
+		return "", ""
+	}
+	return lemma.Translation(effective), effective
+}
+
+// atoneForms strips the quantity marks of forms, dropping the forms that
+// then repeat.
+func atoneForms(forms []string) []string {
+	plain := make([]string, 0, len(forms))
+	for _, f := range forms {
+		if f = collatinus.Atone(f); !slices.Contains(plain, f) {
+			plain = append(plain, f)
+		}
+	}
+	return plain
+}
+
+// writeJSON writes v as the JSON response, indented when the request
+// asks for pretty=true.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(v); err != nil {
+	enc := json.NewEncoder(w)
+	if pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty")); pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
 		log.Printf("encode error: %v", err)
 	}
 }
 
-func writeError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, errorResponse{Error: msg})
+func writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	writeJSON(w, r, status, errorResponse{Error: msg})
 }
 
 // ---- handlers -----------------------------------------------------------
@@ -151,96 +434,694 @@ func writeError(w http.ResponseWriter, status int, msg string) {
 func handleLemmatizeWord(lem *collatinus.Lemmatizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			writeError(w, http.StatusMethodNotAllowed, "GET required")
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
 			return
 		}
 		form := r.URL.Query().Get("form")
 		if form == "" {
-			writeError(w, http.StatusBadRequest, "missing 'form' query parameter")
+			writeError(w, r, http.StatusBadRequest, "missing 'form' query parameter")
 			return
 		}
 		sentenceStart, _ := strconv.ParseBool(r.URL.Query().Get("sentence_start"))
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
+			return
+		}
+
+		lang := parseLang(lem, r)
 
 		analyses := lem.LemmatizeWord(form, sentenceStart)
 		status := http.StatusOK
 		if len(analyses) == 0 {
 			status = http.StatusNotFound
 		}
-		writeJSON(w, status, lemmatizeWordResponse{
+		writeJSON(w, r, status, lemmatizeWordResponse{
 			Form:     form,
-			Analyses: toAnalysesJSON(analyses),
+			Lang:     lang,
+			Analyses: toAnalysesJSON(analyses, marks, lang),
 		})
 	}
 }
 
+// maxBatchForms caps the forms of one /api/lemmatize/batch request, and
+// maxBatchBytes the size of its body, or of one /api/inflection/batch,
+// read no further.
+const (
+	maxBatchForms = 1000
+	maxBatchBytes = 1 << 20
+)
+
+func handleLemmatizeBatch(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, r, http.StatusMethodNotAllowed, "POST required")
+			return
+		}
+		var body struct {
+			Forms         []string `json:"forms"`
+			SentenceStart bool     `json:"sentence_start"`
+		}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&body)
+		if tooLarge := (*http.MaxBytesError)(nil); errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("body larger than %d bytes", maxBatchBytes))
+			return
+		}
+		if err != nil || len(body.Forms) == 0 {
+			writeError(w, r, http.StatusBadRequest, "body must be JSON with a non-empty 'forms' list")
+			return
+		}
+		if len(body.Forms) > maxBatchForms {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("at most %d forms per batch", maxBatchForms))
+			return
+		}
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
+			return
+		}
+
+		lang := parseLang(lem, r)
+
+		results := make([]lemmatizeWordResponse, 0, len(body.Forms))
+		for _, form := range body.Forms {
+			results = append(results, lemmatizeWordResponse{
+				Form:     form,
+				Lang:     lang,
+				Analyses: toAnalysesJSON(lem.LemmatizeWord(form, body.SentenceStart), marks, lang),
+			})
+		}
+		writeJSON(w, r, http.StatusOK, lemmatizeBatchResponse{Lang: lang, Results: results})
+	}
+}
+
 func handleLemmatizeText(lem *collatinus.Lemmatizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			writeError(w, http.StatusMethodNotAllowed, "POST required")
+			writeError(w, r, http.StatusMethodNotAllowed, "POST required")
 			return
 		}
 		var body struct {
 			Text string `json:"text"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Text == "" {
-			writeError(w, http.StatusBadRequest, "body must be JSON with a non-empty 'text' field")
+			writeError(w, r, http.StatusBadRequest, "body must be JSON with a non-empty 'text' field")
+			return
+		}
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
 			return
 		}
 
+		switch r.URL.Query().Get("format") {
+		case "":
+		case "spacy":
+			// the layout of spaCy's Doc.to_json, for Doc.from_json
+			writeJSON(w, r, http.StatusOK, lem.SpacyDoc(body.Text))
+			return
+		default:
+			writeError(w, r, http.StatusBadRequest, "'format' must be empty or 'spacy'")
+			return
+		}
+
+		lang := parseLang(lem, r)
 		results := lem.LemmatizeText(body.Text)
 		out := make([]tokenResultJSON, 0, len(results))
 		for _, res := range results {
 			out = append(out, tokenResultJSON{
 				Token:    res.Token,
-				Analyses: toAnalysesJSON(res.Analyses),
+				Analyses: toAnalysesJSON(res.Analyses, marks, lang),
 			})
 		}
-		writeJSON(w, http.StatusOK, lemmatizeTextResponse{Results: out})
+		writeJSON(w, r, http.StatusOK, lemmatizeTextResponse{Lang: lang, Results: out})
+	}
+}
+
+func handleIncremental(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		prefix := r.URL.Query().Get("prefix")
+		if prefix == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'prefix' query parameter")
+			return
+		}
+		limit := 20
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeError(w, r, http.StatusBadRequest, "'limit' must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		lang := parseLang(lem, r)
+
+		c := lem.Complete(prefix, limit)
+		lemmas := make([]lemmaJSON, 0, len(c.Lemmas))
+		for _, lemma := range c.Lemmas {
+			lemmas = append(lemmas, toLemmaJSON(lemma))
+		}
+		writeJSON(w, r, http.StatusOK, incrementalResponse{
+			Prefix:   prefix,
+			Lang:     lang,
+			Lemmas:   lemmas,
+			Analyses: toAnalysesJSON(c.Analyses, true, lang),
+		})
+	}
+}
+
+func handleSearch(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		prefix := r.URL.Query().Get("prefix")
+		if prefix == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'prefix' query parameter")
+			return
+		}
+		limit := 20
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeError(w, r, http.StatusBadRequest, "'limit' must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		found := lem.LemmasWithPrefix(prefix, limit)
+		lemmas := make([]lemmaJSON, 0, len(found))
+		for _, lemma := range found {
+			lemmas = append(lemmas, toLemmaJSON(lemma))
+		}
+		writeJSON(w, r, http.StatusOK, searchResponse{Prefix: prefix, Lemmas: lemmas})
 	}
 }
 
 func handleInflection(lem *collatinus.Lemmatizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			writeError(w, http.StatusMethodNotAllowed, "GET required")
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		key := r.URL.Query().Get("lemma")
+		if key == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'lemma' query parameter")
+			return
+		}
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
+			return
+		}
+		lemma := lem.Lemma(key)
+		if lemma == nil {
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("lemma %q not found", key))
+			return
+		}
+		lj := toLemmaJSON(lemma)
+		cells := inflectionCells(lem, lemma, marks)
+		writeJSON(w, r, http.StatusOK, inflectionResponse{
+			Lemma:    &lj,
+			Cells:    cells,
+			CellList: cellList(lem, lemma, cells),
+		})
+	}
+}
+
+// maxBatchLemmas caps the lemma keys of one /api/inflection/batch request.
+const maxBatchLemmas = 500
+
+func handleInflectionBatch(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, r, http.StatusMethodNotAllowed, "POST required")
+			return
+		}
+		var body struct {
+			Lemmas []string `json:"lemmas"`
+		}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&body)
+		if tooLarge := (*http.MaxBytesError)(nil); errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("body larger than %d bytes", maxBatchBytes))
+			return
+		}
+		if err != nil || len(body.Lemmas) == 0 {
+			writeError(w, r, http.StatusBadRequest, "body must be JSON with a non-empty 'lemmas' list")
+			return
+		}
+		if len(body.Lemmas) > maxBatchLemmas {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("at most %d lemmas per batch", maxBatchLemmas))
+			return
+		}
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
+			return
+		}
+
+		tables := make(map[string]inflectionBatchEntry, len(body.Lemmas))
+		for _, key := range body.Lemmas {
+			lemma := lem.Lemma(key)
+			if lemma == nil {
+				tables[key] = inflectionBatchEntry{Error: fmt.Sprintf("lemma %q not found", key)}
+				continue
+			}
+			lj := toLemmaJSON(lemma)
+			cells := inflectionCells(lem, lemma, marks)
+			tables[key] = inflectionBatchEntry{Lemma: &lj, Cells: cells, CellList: cellList(lem, lemma, cells)}
+		}
+		writeJSON(w, r, http.StatusOK, inflectionBatchResponse{Tables: tables})
+	}
+}
+
+// inflectionCells returns the inflection table of lemma keyed by morpho
+// index, without quantity marks unless marks is set. A lemma without a
+// model has no cells.
+func inflectionCells(lem *collatinus.Lemmatizer, lemma *collatinus.Lemma, marks bool) map[string][]string {
+	table := lem.InflectionTable(lemma)
+	if table == nil {
+		return map[string][]string{}
+	}
+	cells := make(map[string][]string, len(table.Cells))
+	for idx, forms := range table.Cells {
+		if !marks {
+			forms = atoneForms(forms)
+		}
+		cells[strconv.Itoa(idx)] = forms
+	}
+	return cells
+}
+
+// cellList returns the cells of inflectionCells sorted by morpho index,
+// with the descriptions of the indices for lemma.
+func cellList(lem *collatinus.Lemmatizer, lemma *collatinus.Lemma, cells map[string][]string) []cellJSON {
+	list := make([]cellJSON, 0, len(cells))
+	for key, forms := range cells {
+		idx, _ := strconv.Atoi(key)
+		list = append(list, cellJSON{
+			MorphoIndex:       idx,
+			MorphoDescription: lem.MorphoOf(lemma, idx),
+			Forms:             forms,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].MorphoIndex < list[j].MorphoIndex })
+	return list
+}
+
+func handleInflectionQuery(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		key := r.URL.Query().Get("lemma")
+		if key == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'lemma' query parameter")
+			return
+		}
+		feats := r.URL.Query().Get("feats")
+		if feats == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'feats' query parameter")
+			return
+		}
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
+			return
+		}
+		lemma := lem.Lemma(key)
+		if lemma == nil {
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("lemma %q not found", key))
+			return
+		}
+		found, err := lem.InflectionByFeatures(lemma, feats)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		cells := make([]featureCellJSON, 0, len(found))
+		for idx, forms := range found {
+			if !marks {
+				forms = atoneForms(forms)
+			}
+			cells = append(cells, featureCellJSON{
+				MorphoIndex:       idx,
+				MorphoDescription: lem.MorphoOf(lemma, idx),
+				Feats:             lem.UDFeatures(idx),
+				Forms:             forms,
+			})
+		}
+		sort.Slice(cells, func(i, j int) bool {
+			return cells[i].MorphoIndex < cells[j].MorphoIndex
+		})
+		lj := toLemmaJSON(lemma)
+		writeJSON(w, r, http.StatusOK, inflectionQueryResponse{Lemma: &lj, Feats: feats, Cells: cells})
+	}
+}
+
+func handleForms(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
 			return
 		}
 		key := r.URL.Query().Get("lemma")
 		if key == "" {
-			writeError(w, http.StatusBadRequest, "missing 'lemma' query parameter")
+			writeError(w, r, http.StatusBadRequest, "missing 'lemma' query parameter")
 			return
 		}
 		lemma := lem.Lemma(key)
 		if lemma == nil {
-			writeError(w, http.StatusNotFound, fmt.Sprintf("lemma %q not found", key))
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("lemma %q not found", key))
 			return
 		}
-		table := lem.InflectionTable(lemma)
+		// A lemma without a model (bene) has no table, and no forms.
+		var cells map[int][]string
+		if table := lem.InflectionTable(lemma); table != nil {
+			cells = table.Cells
+		}
+
+		// group the cells by marked form
+		byForm := make(map[string][]int)
+		for idx, forms := range cells {
+			for _, f := range forms {
+				byForm[f] = append(byForm[f], idx)
+			}
+		}
+		forms := make([]formOfJSON, 0, len(byForm))
+		for f, indices := range byForm {
+			sort.Ints(indices)
+			forms = append(forms, formOfJSON{
+				Form:          collatinus.Atone(f),
+				FormWithMarks: f,
+				MorphoIndices: indices,
+			})
+		}
+		// sort by first morpho index, then form, for deterministic output
+		sort.Slice(forms, func(i, j int) bool {
+			if forms[i].MorphoIndices[0] != forms[j].MorphoIndices[0] {
+				return forms[i].MorphoIndices[0] < forms[j].MorphoIndices[0]
+			}
+			return forms[i].FormWithMarks < forms[j].FormWithMarks
+		})
 
-		cells := make(map[string][]string, len(table.Cells))
-		for idx, forms := range table.Cells {
-			cells[strconv.Itoa(idx)] = forms
+		plain := make([]string, 0, len(forms))
+		seen := make(map[string]bool)
+		for _, f := range forms {
+			if !seen[f.Form] {
+				seen[f.Form] = true
+				plain = append(plain, f.Form)
+			}
 		}
 		lj := toLemmaJSON(lemma)
-		writeJSON(w, http.StatusOK, inflectionResponse{Lemma: &lj, Cells: cells})
+		writeJSON(w, r, http.StatusOK, formsResponse{Lemma: &lj, Forms: forms, PlainForms: plain})
+	}
+}
+
+func handleFormsMatch(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		pattern := r.URL.Query().Get("pattern")
+		if strings.Trim(pattern, "*?") == "" {
+			writeError(w, r, http.StatusBadRequest, "'pattern' must contain at least one letter")
+			return
+		}
+		limit := 100
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeError(w, r, http.StatusBadRequest, "'limit' must be a positive integer")
+				return
+			}
+			limit = n
+		}
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
+			return
+		}
+
+		// One more than limit tells whether the matches were truncated.
+		found, err := lem.FindFormsMatching(pattern, limit+1)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := formsMatchResponse{Pattern: pattern, Matches: make([]formMatchJSON, 0, min(len(found), limit))}
+		if len(found) > limit {
+			found, resp.Truncated = found[:limit], true
+		}
+		for _, m := range found {
+			form := m.Form
+			if !marks {
+				form = collatinus.Atone(form)
+			}
+			resp.Matches = append(resp.Matches, formMatchJSON{
+				Form:              form,
+				Lemma:             toLemmaJSON(m.Lemma),
+				MorphoIndex:       m.MorphoIndex,
+				MorphoDescription: lem.MorphoOf(m.Lemma, m.MorphoIndex),
+			})
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+func handleLemmas(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		model := r.URL.Query().Get("model")
+		if model == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'model' query parameter")
+			return
+		}
+		if lem.Model(model) == nil {
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("model %q not found", model))
+			return
+		}
+		derived, _ := strconv.ParseBool(r.URL.Query().Get("derived"))
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeError(w, r, http.StatusBadRequest, "'limit' must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		found := lem.LemmasByModel(model, derived)
+		if limit > 0 && len(found) > limit {
+			found = found[:limit]
+		}
+		lemmas := make([]lemmaJSON, 0, len(found))
+		for _, lemma := range found {
+			lemmas = append(lemmas, toLemmaJSON(lemma))
+		}
+		writeJSON(w, r, http.StatusOK, lemmasResponse{Model: model, Lemmas: lemmas})
+	}
+}
+
+func handleTranslation(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		key := r.URL.Query().Get("lemma")
+		if key == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'lemma' query parameter")
+			return
+		}
+		lemma := lem.Lemma(key)
+		if lemma == nil {
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("lemma %q not found", key))
+			return
+		}
+		langs := lemma.TranslationLanguages()
+		lang := r.URL.Query().Get("lang")
+		if !slices.Contains(langs, lang) {
+			lang = "fr"
+		}
+		writeJSON(w, r, http.StatusOK, translationResponse{
+			Lemma:       lemma.Key,
+			Lang:        lang,
+			Translation: lemma.Translation(lang),
+			Languages:   langs,
+		})
+	}
+}
+
+func handleLemma(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			writeError(w, r, http.StatusBadRequest, "missing 'key' query parameter")
+			return
+		}
+		lemma := lem.Lemma(key)
+		if lemma == nil {
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("lemma %q not found", key))
+			return
+		}
+		resp := lemmaResponse{
+			Lemma:        toLemmaJSON(lemma),
+			NbOcc:        lemma.NbOcc,
+			References:   []string{},
+			Radicals:     lemma.RadicalNumbers(),
+			Translations: make(map[string]string),
+		}
+		if m := lemma.Model(); m != nil {
+			resp.Model = m.Name
+		}
+		for _, ref := range lemma.ReferencesOut() {
+			resp.References = append(resp.References, ref.Key)
+		}
+		for _, lang := range lemma.TranslationLanguages() {
+			resp.Translations[lang] = lemma.Translation(lang)
+		}
+		writeJSON(w, r, http.StatusOK, resp)
 	}
 }
 
 func handleLanguages(lem *collatinus.Lemmatizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			writeError(w, http.StatusMethodNotAllowed, "GET required")
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
 			return
 		}
-		writeJSON(w, http.StatusOK, languagesResponse{Languages: lem.Languages()})
+		writeJSON(w, r, http.StatusOK, languagesResponse{Languages: lem.Languages()})
 	}
 }
 
+func handleScan(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, r, http.StatusMethodNotAllowed, "POST required")
+			return
+		}
+		var body struct {
+			Line string `json:"line"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Line) == "" {
+			writeError(w, r, http.StatusBadRequest, "body must be JSON with a non-empty 'line' field")
+			return
+		}
+		opts := collatinus.ScanOptions{ElideFinalM: true}
+		if v := r.URL.Query().Get("elide_m"); v != "" {
+			elide, err := strconv.ParseBool(v)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "'elide_m' must be a boolean")
+				return
+			}
+			opts.ElideFinalM = elide
+		}
+		marks, err := parseMarks(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "'marks' must be a boolean")
+			return
+		}
+
+		feet := lem.ScanLineWithOptions(body.Line, opts)
+		resp := scanResponse{
+			Line:     body.Line,
+			Valid:    feet != nil,
+			Feet:     []footJSON{},
+			Elisions: []string{},
+			Caesura:  collatinus.Caesura(feet),
+		}
+		for _, f := range feet {
+			fj := footJSON{Kind: f.Kind.String()}
+			for _, s := range f.Syllables {
+				if !marks {
+					s.Text = collatinus.Atone(s.Text)
+				}
+				fj.Syllables = append(fj.Syllables, syllableJSON{Text: s.Text, Long: s.Long, WordEnd: s.WordEnd})
+				if strings.Contains(s.Text, "‿") {
+					resp.Elisions = append(resp.Elisions, s.Text)
+				}
+			}
+			resp.Feet = append(resp.Feet, fj)
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+func handleVersion(lem *collatinus.Lemmatizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "GET required")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, versionResponse{
+			Version:         Version,
+			DataFingerprint: lem.DataFingerprint(),
+		})
+	}
+}
+
+// loadFormIndex loads the form index of lem from path, or builds it and
+// saves it to path when it cannot be loaded.
+func loadFormIndex(lem *collatinus.Lemmatizer, path string) {
+	f, err := os.Open(path)
+	if err == nil {
+		err = lem.LoadFormIndex(bufio.NewReader(f))
+		f.Close()
+		if err == nil {
+			log.Printf("form index loaded from %s", path)
+			return
+		}
+	}
+	log.Printf("cannot load form index (%v), building it", err)
+	lem.BuildFormIndex()
+	f, err = os.Create(path)
+	if err != nil {
+		log.Printf("cannot save form index: %v", err)
+		return
+	}
+	w := bufio.NewWriter(f)
+	err = lem.WriteFormIndex(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Printf("cannot save form index: %v", err)
+		return
+	}
+	log.Printf("form index built and saved to %s", path)
+}
+
 // ---- main ---------------------------------------------------------------
 
 func main() {
 	dataDir := flag.String("data", "data", "path to Collatinus data directory")
 	addr := flag.String("addr", ":8080", "listen address")
+	formIndex := flag.Bool("form-index", false, "precompute the analyses of every generable form at startup (faster lookups, more memory)")
+	formIndexFile := flag.String("form-index-file", "", "load the form index from this file, or build it and save it there when the file is missing or stale (implies -form-index)")
+	formsMatch := flag.Bool("forms-match", false, "serve /api/forms/match, building the form index it needs at startup (implies -form-index)")
+	precomputeInflections := flag.Bool("precompute-inflections", false, "compute the inflection table of every lemma at startup instead of on first request (faster first lookups, about 165 MB more memory)")
 	corsOrigins := flag.String("cors", "", "comma-separated list of allowed CORS origins (e.g. https://a.com,https://b.com); use * to allow all")
 	flag.Parse()
 
@@ -250,16 +1131,49 @@ func main() {
 		log.Fatalf("failed to load data: %v", err)
 	}
 	log.Println("data loaded")
+	if *formIndexFile != "" {
+		loadFormIndex(lem, *formIndexFile)
+	} else if *formIndex || *formsMatch {
+		lem.BuildFormIndex()
+		log.Println("form index built")
+	}
+	if *precomputeInflections {
+		n := 0
+		for lemma := range lem.Lemmas() {
+			lem.InflectionTable(lemma)
+			n++
+		}
+		log.Printf("%d inflection tables precomputed", n)
+	}
+
+	var origins []string
+	if *corsOrigins != "" {
+		origins = strings.Split(*corsOrigins, ",")
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/lemmatize/text", handleLemmatizeText(lem))
+	mux.HandleFunc("/api/lemmatize/incremental", handleIncremental(lem))
+	mux.HandleFunc("/api/lemmatize/batch", handleLemmatizeBatch(lem))
+	mux.HandleFunc("/api/search", handleSearch(lem))
 	mux.HandleFunc("/api/lemmatize", handleLemmatizeWord(lem))
 	mux.HandleFunc("/api/inflection", handleInflection(lem))
+	mux.HandleFunc("/api/inflection/query", handleInflectionQuery(lem))
+	mux.HandleFunc("/api/inflection/batch", handleInflectionBatch(lem))
+	mux.HandleFunc("/api/forms", handleForms(lem))
+	if *formsMatch {
+		mux.HandleFunc("/api/forms/match", handleFormsMatch(lem))
+	}
+	mux.HandleFunc("/api/lemmas", handleLemmas(lem))
+	mux.HandleFunc("/api/translation", handleTranslation(lem))
+	mux.HandleFunc("/api/lemma", handleLemma(lem))
 	mux.HandleFunc("/api/languages", handleLanguages(lem))
+	mux.HandleFunc("/api/scan", handleScan(lem))
+	mux.HandleFunc("/api/version", handleVersion(lem))
+	mux.HandleFunc("/ws/lemmatize", handleLemmatizeWS(lem, origins))
 
 	var handler http.Handler = mux
-	if *corsOrigins != "" {
-		origins := strings.Split(*corsOrigins, ",")
+	if len(origins) > 0 {
 		handler = cors.New(cors.Options{
 			AllowedOrigins: origins,
 			AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodOptions},
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	collatinus "github.com/cours-de-latin/collatinus"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsMaxMessage is the largest word message accepted, in bytes.
+	wsMaxMessage = 1024
+	// wsWriteWait is the time allowed to write a message to the peer.
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is the time allowed to read the next pong from the peer.
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod sends pings before wsPongWait expires.
+	wsPingPeriod = wsPongWait * 9 / 10
+)
+
+// handleLemmatizeWS serves /ws/lemmatize. Each text message is a word and
+// is answered with a lemmatizeWordResponse, with the translations in the
+// language of the lang query parameter, or none with translation=false.
+// When words arrive faster than they are answered, as while typing, only
+// the latest pending word is answered. origins lists the accepted Origin
+// headers ("*" for any); an empty list accepts same-origin requests only.
+func handleLemmatizeWS(lem *collatinus.Lemmatizer, origins []string) http.HandlerFunc {
+	upgrader := websocket.Upgrader{}
+	if len(origins) > 0 {
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			return slices.Contains(origins, "*") || slices.Contains(origins, r.Header.Get("Origin"))
+		}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := parseLang(lem, r)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already replied with an HTTP error.
+			return
+		}
+		defer conn.Close()
+
+		// pending holds the latest word not yet answered.
+		pending := make(chan string, 1)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			wsWriter(conn, lem, lang, pending)
+		}()
+
+		conn.SetReadLimit(wsMaxMessage)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Printf("websocket read: %v", err)
+				}
+				break
+			}
+			if typ != websocket.TextMessage {
+				continue
+			}
+			form := strings.TrimSpace(string(msg))
+			if form == "" {
+				continue
+			}
+			// replace a word the writer has not picked up yet
+			select {
+			case <-pending:
+			default:
+			}
+			pending <- form
+		}
+		close(pending)
+		<-done
+	}
+}
+
+// wsWriter answers the words of pending until it is closed, pinging the
+// peer in between, then closes the connection with a close message.
+func wsWriter(conn *websocket.Conn, lem *collatinus.Lemmatizer, lang string, pending <-chan string) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case form, ok := <-pending:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			err := conn.WriteJSON(lemmatizeWordResponse{
+				Form:     form,
+				Lang:     lang,
+				Analyses: toAnalysesJSON(lem.LemmatizeWord(form, false), true, lang),
+			})
+			if err != nil {
+				conn.Close()
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -3,11 +3,27 @@
 // without any Qt dependency.
 package collatinus
 
+import (
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
 // Lemmatizer holds all loaded data and provides the public API.
 type Lemmatizer struct {
+	// mu guards the data against Reload: the methods safe to call while
+	// it runs hold it for reading.
+	mu sync.RWMutex
+
 	// morphos stores morphological descriptions indexed 1-based.
 	// Index 0 is unused; morphos[1] = "nominatif singulier", etc.
 	morphos []string
+	// morphologies holds the features of each of morphos.
+	morphologies []Morphology
 
 	// models maps model name → *Model.
 	models map[string]*Model
@@ -35,12 +51,120 @@ type Lemmatizer struct {
 
 	// contractions maps contracted ending → expanded ending.
 	contractions map[string]string
+
+	// labelLocale selects the built-in label table used by Label; empty
+	// means the data-file descriptions.
+	labelLocale string
+
+	// citationStyle is the style of LexiconCitation.
+	citationStyle CitationStyle
+
+	// opts holds the optional lemmatization behaviours.
+	opts LemmatizeOptions
+
+	// fingerprint identifies the loaded data; see DataFingerprint.
+	fingerprint string
+
+	// warnings collects the non-fatal problems found while loading.
+	warnings []LoadWarning
+
+	// config holds the Options of New, which Reload keeps.
+	config loadConfig
+
+	// strict makes the loaders warn about the lines they skip; see
+	// ValidateData.
+	strict bool
+
+	// rules are the disambiguation rules applied by DisambiguateText.
+	rules []DisambiguationRule
+
+	// prefixes lists radicals, irregular and canonical forms sorted by
+	// key, for Complete.
+	prefixes []prefixEntry
+	// sortedLemmas lists the lemmas sorted by key, for LemmasWithPrefix.
+	sortedLemmas []*Lemma
+
+	// tables caches the tables of InflectionTable, by *Lemma.
+	tables sync.Map
+
+	// index caches the raw analyses of every generable form; nil until
+	// BuildFormIndex is called.
+	index formIndex
+}
+
+// Option configures what New loads; see WithLanguages and
+// WithoutTranslations.
+type Option func(*loadConfig)
+
+// loadConfig gathers the Options given to New. The zero value loads
+// everything.
+type loadConfig struct {
+	// languages, when not nil, lists the translation languages to load.
+	languages []string
+	// noTranslations skips the lemmes.XX files.
+	noTranslations bool
 }
 
 // New loads all Collatinus data from dataDir (the path to bin/data/)
-// and returns a ready-to-use Lemmatizer.
-func New(dataDir string) (*Lemmatizer, error) {
+// and returns a ready-to-use Lemmatizer. Without options every lemmes.XX
+// translation file is loaded.
+func New(dataDir string, opts ...Option) (*Lemmatizer, error) {
+	var c loadConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return load(dataDir, false, c)
+}
+
+// WithLanguages makes New load only the translations of the given
+// language codes ("fr", "en", ...), saving memory and startup time.
+func WithLanguages(codes ...string) Option {
+	return func(c *loadConfig) {
+		if c.languages == nil {
+			c.languages = []string{}
+		}
+		c.languages = append(c.languages, codes...)
+	}
+}
+
+// WithoutTranslations makes New load no translations at all.
+func WithoutTranslations() Option {
+	return func(c *loadConfig) {
+		c.noTranslations = true
+	}
+}
+
+// Reload loads the data of dataDir anew and swaps it in, keeping the
+// Options of New, the lemmatization options, the label locale, the
+// citation style and the disambiguation rules, and rebuilding the form
+// index when one was built. The glosses and overlays applied since New
+// are dropped. On error l keeps its data. Reload may run while other
+// goroutines call LemmatizeWord, LemmatizeText, InflectionTable, Lemma
+// and LemmasWithPrefix, which see either the old data or the new; the
+// other methods must not be called meanwhile, nor Reload twice at once.
+// The lemmas returned before belong to the old data.
+func (l *Lemmatizer) Reload(dataDir string) error {
+	return l.reload(dataDir)
+}
+
+// ValidateData loads the data of dataDir strictly, reporting the lines
+// the loaders otherwise skip silently (malformed lines, lemmas of unknown
+// models, irregular forms of unknown lemmas), and cross-checks it: the
+// morpho indices of models and irregular forms must be in range, and the
+// forms of a sample of lemmas must be analysed back as those lemmas. The
+// warnings include those of Warnings, among which the model cells no
+// radical rule reaches. The error is that of New, when the data cannot
+// be loaded at all; no lemmatizer is returned, being meant for checking
+// data rather than serving it.
+func ValidateData(dataDir string) ([]LoadWarning, error) {
+	return validateData(dataDir)
+}
+
+// load implements New; strict makes the loaders report what they skip.
+func load(dataDir string, strict bool, config loadConfig) (*Lemmatizer, error) {
 	l := &Lemmatizer{
+		strict:       strict,
+		config:       config,
 		morphos:      []string{""}, // index 0 unused; 1-based
 		models:       make(map[string]*Model),
 		lemmas:       make(map[string]*Lemma),
@@ -75,10 +199,64 @@ func New(dataDir string) (*Lemmatizer, error) {
 	if err := l.loadIrregs(dataDir); err != nil {
 		return nil, err
 	}
-	// parpos.txt is loaded separately (not needed for core lemmatization)
+	l.addLocatives()
+	l.checkModels()
+	l.linkReferences()
+	l.prefixes = l.buildPrefixIndex()
+	l.sortedLemmas = l.sortLemmas()
+	l.fingerprint = l.computeFingerprint()
+	// parpos.txt is not loaded: its rules mark vowel quantities by
+	// position, for scansion, and lemmatization does not need them.
 	return l, nil
 }
 
+// Warnings returns the non-fatal problems found while loading the data.
+func (l *Lemmatizer) Warnings() []LoadWarning {
+	return l.warnings
+}
+
+// DataFingerprint returns a hex SHA-256 digest of the loaded morphos,
+// models, lemmas, translations and irregular forms. It only changes when
+// the data does, so caches and clients can key on it.
+func (l *Lemmatizer) DataFingerprint() string {
+	return l.fingerprint
+}
+
+// LoadGlossesTSV adds the translations of a combined glosses file, one
+// "lemmaKey<TAB>lang<TAB>gloss" row per line, to those read from the
+// lemmes.XX files; a row replaces the gloss the lemma already has in that
+// language. Rows for unknown lemmas are skipped and reported by Warnings,
+// one warning per skipped row. It must not be called while other
+// goroutines use l.
+func (l *Lemmatizer) LoadGlossesTSV(path string) error {
+	return l.loadGlossesTSV(path)
+}
+
+// ApplyOverlay applies the corrections of an overlay file to the data
+// loaded by New, in order, so that fixes can ship without editing the
+// data files. Each line is a directive "action:entity:data", the action
+// being add, override or delete:
+//
+//	add:lemma:<line of lemmes.la>
+//	override:lemma:<line of lemmes.la>   keeps translations and irregs
+//	delete:lemma:<key>
+//	add:irreg:<line of irregs.la>
+//	override:irreg:<line of irregs.la>   replaces the form of the lemma
+//	delete:irreg:<form>:<lemma key>
+//	add:model:<name>                     followed by the lines of the
+//	override:model:<name>                model, up to a blank line
+//	delete:model:<name>
+//
+// Lines starting with ! are comments. A model other models inherit from
+// cannot be overridden or deleted, nor a model lemmas are inflected on be
+// deleted. The first failing directive stops the overlay with an error
+// giving its line; the directives before it stay applied. Applying an
+// overlay updates DataFingerprint, and rebuilds the form index when it
+// was built. It must not be called while other goroutines use l.
+func (l *Lemmatizer) ApplyOverlay(path string) error {
+	return l.applyOverlay(path)
+}
+
 // Morpho returns the morphological description string for 1-based index m.
 // Mirrors Lemmat::morpho.
 func (l *Lemmatizer) Morpho(m int) string {
@@ -88,18 +266,144 @@ func (l *Lemmatizer) Morpho(m int) string {
 	return l.morphos[m]
 }
 
+// MorphoIndices returns the valid morpho indices, 1 to the number of
+// descriptions, in the order of morphos.fr: the cells of a full
+// paradigm grid, of which InflectionTable only fills those with forms.
+func (l *Lemmatizer) MorphoIndices() []int {
+	indices := make([]int, len(l.morphos)-1)
+	for i := range indices {
+		indices[i] = i + 1
+	}
+	return indices
+}
+
+// AllMorphos returns the morpho descriptions in the order of
+// MorphoIndices, AllMorphos()[i] being Morpho(i+1).
+func (l *Lemmatizer) AllMorphos() []string {
+	return slices.Clone(l.morphos[1:])
+}
+
+// ParseMorpho returns the features of the morpho description of 1-based
+// index m, read from the words of morphos.fr: "accusatif masculin
+// singulier" is CaseAccusative, GenderMasculine and NumberSingular. The
+// features the description does not give, or which are unknown, are
+// zero, as is the whole Morphology of an index out of range.
+func (l *Lemmatizer) ParseMorpho(m int) Morphology {
+	if m < 1 || m >= len(l.morphologies) {
+		return Morphology{}
+	}
+	return l.morphologies[m]
+}
+
+// SetLabelLocale selects the language of the labels returned by Label and
+// the paradigm helpers: "en", "de" or "it" use the built-in label tables,
+// while "fr", "" or any other code use the morphos.fr descriptions. It
+// does not affect the MorphoDescription of analyses. It must not be called
+// while other goroutines use l.
+func (l *Lemmatizer) SetLabelLocale(lang string) {
+	l.labelLocale = lang
+}
+
+// SetCitationStyle selects the style of LexiconCitation, by default
+// CitationAbbreviated. It must not be called while other goroutines use
+// l.
+func (l *Lemmatizer) SetCitationStyle(style CitationStyle) {
+	l.citationStyle = style
+}
+
+// Label returns the description of morpho index m in the label locale set
+// by SetLabelLocale, e.g. "genitive singular" for 4 in English.
+func (l *Lemmatizer) Label(m int) string {
+	desc := l.Morpho(m)
+	if table, ok := labelTables[l.labelLocale]; ok {
+		return translateLabel(desc, table)
+	}
+	return desc
+}
+
+// MorphoOf returns the morphological description of index m as it applies
+// to lemma: the few passive-labelled cells of deponent verbs (future
+// imperative) are relabelled active, since deponents are active in meaning.
+func (l *Lemmatizer) MorphoOf(lemma *Lemma, m int) string {
+	desc := l.Morpho(m)
+	if lemma != nil && lemma.Deponent {
+		desc = strings.ReplaceAll(desc, "passif", "actif")
+	}
+	return desc
+}
+
 // Lemma looks up a lemma by its normalized key.
 func (l *Lemmatizer) Lemma(key string) *Lemma {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.lemmas[NormalizeKey(key)]
 }
 
+// FindLemma looks up every lemma a search query may refer to, whatever its
+// capitalization, quantity marks or j/v and æ/œ spelling: "Caesar",
+// "caesar", "cæsar" and "Cǣsar" all find Caesar. The query is tried as
+// typed, lower-cased and capitalized, each with its homonyms (malus,
+// malus2…), and the matches are returned in that order without duplicates.
+func (l *Lemmatizer) FindLemma(query string) []*Lemma {
+	var found []*Lemma
+	seen := make(map[*Lemma]bool)
+	add := func(key string) {
+		if lemma := l.lemmas[key]; lemma != nil && !seen[lemma] {
+			seen[lemma] = true
+			found = append(found, lemma)
+		}
+	}
+	for _, q := range []string{query, strings.ToLower(query), titleCase(query)} {
+		key := NormalizeKey(q)
+		add(key)
+		// homonym numbers are single digits and may have gaps (ā, ā3)
+		for n := 1; n <= 9; n++ {
+			add(key + strconv.Itoa(n))
+		}
+	}
+	return found
+}
+
 // LemmaByKey looks up a lemma by its already-normalized key.
 func (l *Lemmatizer) LemmaByKey(key string) *Lemma {
 	return l.lemmas[key]
 }
 
+// ReferencesTo returns the lemmas whose "cf." cross-reference points to
+// the lemma with the given key, by key.
+func (l *Lemmatizer) ReferencesTo(key string) []*Lemma {
+	if lemma := l.Lemma(key); lemma != nil {
+		return lemma.refsIn
+	}
+	return nil
+}
+
+// Model looks up an inflection model by name.
+func (l *Lemmatizer) Model(name string) *Model {
+	return l.models[name]
+}
+
+// LemmasByModel returns the lemmas inflected on the named model, or, when
+// includeDerived is true, on that model or any model inheriting from it,
+// the most frequent first.
+func (l *Lemmatizer) LemmasByModel(modelName string, includeDerived bool) []*Lemma {
+	return l.lemmasByModel(modelName, includeDerived)
+}
+
+// Lemmas yields all the lemmas of the lexicon, sorted by key.
+func (l *Lemmatizer) Lemmas() iter.Seq[*Lemma] {
+	return func(yield func(*Lemma) bool) {
+		for _, key := range slices.Sorted(maps.Keys(l.lemmas)) {
+			if !yield(l.lemmas[key]) {
+				return
+			}
+		}
+	}
+}
+
 // Languages returns a map of language-code → language-name for all
-// loaded translation files.
+// loaded translation files: only those of WithLanguages, and none with
+// WithoutTranslations.
 func (l *Lemmatizer) Languages() map[string]string {
 	out := make(map[string]string, len(l.languages))
 	for k, v := range l.languages {
@@ -108,22 +412,422 @@ func (l *Lemmatizer) Languages() map[string]string {
 	return out
 }
 
+// Options returns the lemmatization options currently in effect.
+func (l *Lemmatizer) Options() LemmatizeOptions {
+	return l.opts
+}
+
+// SetLemmatizeOptions replaces the lemmatization options. It must not be
+// called while other goroutines are lemmatizing with l.
+func (l *Lemmatizer) SetLemmatizeOptions(opts LemmatizeOptions) {
+	l.opts = opts
+}
+
 // LemmatizeWord lemmatizes a single Latin word form.
 // If sentenceStart is true the word may be capitalized because it
 // is the first word of a sentence (not necessarily a proper noun).
 // Mirrors Lemmat::lemmatiseM.
 func (l *Lemmatizer) LemmatizeWord(form string, sentenceStart bool) map[*Lemma][]Analysis {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.lemmatizeM(form, sentenceStart)
 }
 
+// LemmatizeWordRanked is LemmatizeWord returning the lemmas in order,
+// the likeliest first: those of the PreferRegisters of the options, then
+// by descending Score, which weighs their NbOcc down when the form only
+// reaches them through enclitic stripping or another rewriting, or the
+// capitalization fallback, then by key.
+func (l *Lemmatizer) LemmatizeWordRanked(form string, sentenceStart bool) []RankedAnalysis {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lemmatizeWordRanked(form, sentenceStart)
+}
+
+// Scores returns the AnalysisScore of each analysis of mm, a result of
+// LemmatizeWord, in the order of mm[lemma], for ranking the analyses by
+// signals weighed as the caller likes.
+func (l *Lemmatizer) Scores(mm map[*Lemma][]Analysis) map[*Lemma][]AnalysisScore {
+	return l.scores(mm)
+}
+
+// DiffLemmatize lemmatizes words with l and with other, typically loaded
+// from edited data, and returns the words whose analyses differ, in
+// order. Analyses are compared by lemma key, morpho index and marked
+// form, so both instances may come from different data directories.
+func (l *Lemmatizer) DiffLemmatize(other *Lemmatizer, words []string) []Diff {
+	return l.diffLemmatize(other, words)
+}
+
+// RankLemmas orders the lemmas of a LemmatizeWord result, the likeliest
+// first: those of the PreferRegisters of the options, then the most
+// frequent, or with PreferLongStems the one with the longest stem among
+// its analyses, then the most frequent.
+func (l *Lemmatizer) RankLemmas(mm map[*Lemma][]Analysis) []*Lemma {
+	return l.rankLemmas(mm)
+}
+
+// IsValidForm reports whether LemmatizeWord(form, false) would find any
+// analysis. Forms that lemmatize directly are accepted without building
+// the analyses.
+func (l *Lemmatizer) IsValidForm(form string) bool {
+	return l.isValidForm(form)
+}
+
+// Explain lemmatizes form and explains each of its lemmas in a sentence
+// for beginners, the most frequent lemma first: "puellae is the
+// genitive/dative singular or nominative/vocative plural of puella
+// (girl), a feminine 1st-declension noun." The sentences are in French
+// for lang "fr" and in English otherwise; the glosses are in lang.
+func (l *Lemmatizer) Explain(form string, lang string) []string {
+	return l.explain(form, lang)
+}
+
 // LemmatizeText splits text into tokens and lemmatizes each word.
 func (l *Lemmatizer) LemmatizeText(text string) []LemmatizationResult {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.lemmatizeText(text)
 }
 
-// InflectionTable computes the full inflection table for a lemma.
+// LemmatizeTextDisambiguated is LemmatizeText with the analyses of each
+// word narrowed down by its neighbours in the sentence. A declined
+// reading is dropped when another reading of the same lemma agrees in
+// case, number and gender with a word next to it and this one does not,
+// an adjective (pronoun, participle) agreeing with a noun or another
+// adjective: "magna puella" keeps puella as a nominative or ablative
+// singular. And when the sentence has a word only read as a nominative
+// but no certain verb, a single word that can be a finite verb keeps its
+// finite readings, those agreeing in number with the subject when some
+// do. A word never loses all its analyses. The rules of AddRule are not
+// applied; see DisambiguateText.
+func (l *Lemmatizer) LemmatizeTextDisambiguated(text string) []LemmatizationResult {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lemmatizeTextDisambiguated(text)
+}
+
+// SegmentContinuous lemmatizes text written without spaces between its
+// words (scriptio continua), or with interpuncts, as inscriptions are:
+// "SENATVS·POPVLVSQVEROMANVS" gives SENATVS, POPVLVSQVE and ROMANVS.
+// Interpuncts, spaces and punctuation are boundaries, and each run of
+// letters between them is cut into the fewest forms the lexicon knows,
+// the longest first word winning ties; letters no form covers make
+// tokens without analyses. Words in capitals are read as with the
+// Inscriptions option. The cut knows nothing of syntax or sense, so
+// where two cuts have as many words it may pick the wrong one, and
+// abbreviations (F for filius) and numerals are not understood. Every
+// substring of a run up to 30 letters long is looked up: BuildFormIndex
+// makes this much faster.
+func (l *Lemmatizer) SegmentContinuous(text string) []LemmatizationResult {
+	return l.segmentContinuous(text)
+}
+
+// Tokens is LemmatizeText yielding its results one by one, each word
+// being lemmatized only when it is reached, for streaming large texts or
+// stopping early.
+func (l *Lemmatizer) Tokens(text string) iter.Seq[LemmatizationResult] {
+	return l.tokens(text)
+}
+
+// LemmatizeReader lemmatizes the text read from r like Tokens, reading
+// it by chunks of lines so that large files need not be held in memory,
+// and calls fn with each word's result, Start and End being offsets in
+// the whole (composed) text. Sentence starts are found across chunks.
+// With Options.Inscriptions, words are read as an inscription's when
+// most of their chunk is in capitals. It stops at the first error of fn
+// or r and returns it.
+func (l *Lemmatizer) LemmatizeReader(r io.Reader, fn func(LemmatizationResult) error) error {
+	return l.lemmatizeReader(r, fn)
+}
+
+// Macronize returns text with the long vowels of its words marked with
+// macrons, composed to NFC. A vowel is marked when the analyses that
+// spell the word, an enclitic aside, agree that it is long; when they
+// disagree, those of the lemma of highest NbOcc decide, and a vowel on
+// which they still disagree is left unmarked, as are short vowels. As in
+// the lexicon, vowels long by position are marked too. The capitals,
+// punctuation and words without analyses are kept as they are.
+func (l *Lemmatizer) Macronize(text string) string {
+	return l.macronize(text)
+}
+
+// SpacyDoc lemmatizes and disambiguates text like DisambiguateText and
+// returns it in the JSON layout of spaCy's Doc.from_json, keeping for each
+// word the first analysis of its most frequent lemma.
+func (l *Lemmatizer) SpacyDoc(text string) SpacyDoc {
+	return l.spacyDoc(text)
+}
+
+// ExportCoNLLU writes results, as LemmatizeText returns them, to w in the
+// CoNLL-U format, one sentence per block from each word Tokenize finds
+// starting a sentence. A token is lemmatized by its first lemma in
+// RankLemmas order, with the UD tag of the lemma and the UD features of
+// its first analysis; the other lemmas go to the MISC column as
+// AltLemmas. A token without analyses has itself as lemma and X as tag.
+// Punctuation is not among the results and so not written, and the HEAD
+// and DEPREL columns are left to a parser.
+func (l *Lemmatizer) ExportCoNLLU(results []LemmatizationResult, w io.Writer) error {
+	return l.exportCoNLLU(results, w)
+}
+
+// CoverageVocabulary returns the fewest lemmas covering targetPct
+// percent of the words of text, the most frequent in the text first, for
+// choosing what to learn before reading it. Each word counts for the
+// lemma DisambiguateText ranks first; unknown words count in the total
+// but cannot be covered, so when the target is out of reach all the
+// lemmas of the text are returned.
+func (l *Lemmatizer) CoverageVocabulary(text string, targetPct float64) []*Lemma {
+	return l.coverageVocabulary(text, targetPct)
+}
+
+// UDFeatures returns the Universal Dependencies features of morpho index
+// m, sorted and pipe-separated: "Case=Gen|Number=Sing" for 4.
+func (l *Lemmatizer) UDFeatures(m int) string {
+	return udFeats(l.Morpho(m))
+}
+
+// CertainFeatures returns what all the analyses of form agree on, for
+// tagging only what is unambiguous: "bonam" is surely the Case=Acc,
+// Gender=Fem, Number=Sing of bonus, while "puellae" is only surely the
+// Gender=Fem of puella, a noun having the gender of its lemma. Lemma is
+// nil when several lemmas remain, and Feats is empty when form is
+// unknown.
+func (l *Lemmatizer) CertainFeatures(form string) MorphoFeatures {
+	return l.certainFeatures(form)
+}
+
+// VerbMood returns the imperative and infinitive analyses of form as a
+// verb, sorted by lemma key and morpho index, for telling commands
+// apart quickly: "venite" is the 2nd plural imperative of uenio (and
+// ueneo). Only the form itself is looked up, without enclitics,
+// contractions or the other fallbacks of LemmatizeWord.
+func (l *Lemmatizer) VerbMood(form string) []MoodMatch {
+	return l.verbMood(form)
+}
+
+// ScanLine fits line to a dactylic hexameter, from the quantities of the
+// lexicon forms of its words and the rules of length by position and of
+// elision, and returns its six feet, or nil when it does not fit. A word
+// read several ways is tried each way; the fifth foot is a dactyl when it
+// can be. It is ScanLineWithOptions with ElideFinalM, as verse is read.
+func (l *Lemmatizer) ScanLine(line string) []Foot {
+	return l.scanLine(line, ScanOptions{ElideFinalM: true})
+}
+
+// ScanLineWithOptions is ScanLine with the options of opts.
+func (l *Lemmatizer) ScanLineWithOptions(line string, opts ScanOptions) []Foot {
+	return l.scanLine(line, opts)
+}
+
+// AddRule registers a disambiguation rule. Rules run in the order they
+// were added. AddRule must not be called while other goroutines use l.
+func (l *Lemmatizer) AddRule(r DisambiguationRule) {
+	l.rules = append(l.rules, r)
+}
+
+// DisambiguateText is LemmatizeText followed by the registered
+// disambiguation rules.
+func (l *Lemmatizer) DisambiguateText(text string) []LemmatizationResult {
+	return l.disambiguateText(text)
+}
+
+// LemmasWithPrefix returns the lemmas whose key starts with prefix, or
+// with prefix capitalized ("rom" gives Roma), both normalized as keys,
+// sorted by key; at most limit of them, limit <= 0 meaning all. Unlike
+// Complete, it looks at the lexicon entries only, not at their forms,
+// for searching the dictionary.
+func (l *Lemmatizer) LemmasWithPrefix(prefix string, limit int) []*Lemma {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lemmasWithPrefix(prefix, limit)
+}
+
+// Complete returns the lemmas that have a form starting with prefix (at
+// most limit of them, the most frequent first; limit <= 0 means all),
+// together with the analyses of prefix when it is already a full form.
+// It is meant for incremental lookup while a word is being typed.
+func (l *Lemmatizer) Complete(prefix string, limit int) Completion {
+	return l.complete(prefix, limit)
+}
+
+// StemOf cuts form into a stem and an ending in its own spelling, for
+// highlighting: "Rosārum" gives "Ros", "ārum" and "Musæ" gives "Mus", "æ".
+// The cut is that of the most frequent lemma among the segmentations the
+// lemmatizer accepts. ok is false when there is none, as for irregular
+// forms.
+func (l *Lemmatizer) StemOf(form string) (stem, ending string, ok bool) {
+	return l.stemOf(form)
+}
+
+// Segmentations exposes the search space of the lemmatizer: every cut of
+// form into a known radical and a known desinence, whether or not they
+// belong to the same model.
+func (l *Lemmatizer) Segmentations(form string) []Segmentation {
+	return l.segmentations(form)
+}
+
+// BuildFormIndex precomputes the raw analyses of every form generable from
+// the lexicon, trading memory (about 250 MB with the bundled data) and
+// several seconds of startup for faster lemmatization of known forms.
+// Forms that are not indexed, or that use j/v or æ/œ spellings, still go
+// through the dynamic segmentation. It must not be called while other
+// goroutines are lemmatizing with l.
+func (l *Lemmatizer) BuildFormIndex() {
+	l.index = l.buildFormIndex()
+}
+
+// WriteFormIndex writes the form index, gzip-compressed, so that
+// LoadFormIndex can restore it instead of BuildFormIndex recomputing it.
+// It builds the index first if BuildFormIndex has not been called, and so
+// must not be called while other goroutines are lemmatizing with l.
+func (l *Lemmatizer) WriteFormIndex(w io.Writer) error {
+	if l.index == nil {
+		l.index = l.buildFormIndex()
+	}
+	return l.writeFormIndex(w, l.index)
+}
+
+// LoadFormIndex replaces BuildFormIndex by reading an index written by
+// WriteFormIndex. It fails, leaving l unchanged, when the index was
+// written by another format version or from data with another
+// DataFingerprint. It must not be called while other goroutines are
+// lemmatizing with l.
+func (l *Lemmatizer) LoadFormIndex(r io.Reader) error {
+	idx, err := l.readFormIndex(r)
+	if err != nil {
+		return err
+	}
+	l.index = idx
+	return nil
+}
+
+// GenerateAllForms inflects every lemma of the lexicon and returns all the
+// generated forms, ordered by lemma key and morpho index.
+func (l *Lemmatizer) GenerateAllForms() []GeneratedForm {
+	var forms []GeneratedForm
+	l.generateAllForms(func(g GeneratedForm) {
+		forms = append(forms, g)
+	})
+	return forms
+}
+
+// FindFormsMatching returns the indexed forms matching pattern, where
+// "?" stands for any one letter and "*" for any run of letters: "p??lla"
+// finds puella. Case, quantity marks and the j/v spelling are ignored.
+// The matches come in form order, at most limit of them, limit <= 0
+// meaning all. It searches the form index, and fails when neither
+// BuildFormIndex nor LoadFormIndex has run, rather than inflect the
+// whole lexicon for each pattern.
+func (l *Lemmatizer) FindFormsMatching(pattern string, limit int) ([]FormMatch, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.findFormsMatching(pattern, limit)
+}
+
+// AmbiguityReport lists the forms, without quantity marks, that inflect
+// more than one lemma of the lexicon, in form order. Each carries the
+// competing lemmas.
+func (l *Lemmatizer) AmbiguityReport() []AmbiguousForm {
+	var report []AmbiguousForm
+	l.eachAmbiguousForm(func(a AmbiguousForm) bool {
+		report = append(report, a)
+		return true
+	})
+	return report
+}
+
+// EachAmbiguousForm calls fn for each form AmbiguityReport would list,
+// stopping when fn returns false. It does not save memory: every form
+// of the lexicon is inflected and bucketed before the first call, and
+// only the report list itself is not built.
+func (l *Lemmatizer) EachAmbiguousForm(fn func(AmbiguousForm) bool) {
+	l.eachAmbiguousForm(fn)
+}
+
+// InflectionTable computes the full inflection table for a lemma. The
+// table is cached, so that later calls for the lemma return it at once;
+// it is shared and must not be modified. The cache is not bounded: it
+// keeps every table asked for until InvalidateInflectionCache, about
+// 165 MB for all the lemmas of the bundled data.
 func (l *Lemmatizer) InflectionTable(lemma *Lemma) *InflectionTable {
-	return l.inflectionTable(lemma)
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cachedInflectionTable(lemma)
+}
+
+// InvalidateInflectionCache empties the cache of InflectionTable. Reload
+// and ApplyOverlay do so themselves; it is only needed after changing
+// lemmas or models by other means.
+func (l *Lemmatizer) InvalidateInflectionCache() {
+	l.tables.Clear()
+}
+
+// ExportInflectionCSV writes table to w as CSV: a metadata row "lemma",
+// key, canonical form, a header row, then one row per cell in the order
+// of the morpho indices, with the index, its description (as MorphoOf
+// gives it) and the forms joined by ", ". A nil table gives the header
+// alone. It is a method for the descriptions, which the table lacks.
+func (l *Lemmatizer) ExportInflectionCSV(table *InflectionTable, w io.Writer) error {
+	return l.exportInflectionCSV(table, w)
+}
+
+// FormsFor returns the forms of lemma whose morphology has the features
+// of filter, its Unspecified fields matching anything: Case
+// CaseGenitive and Number NumberPlural give the genitive plural of a
+// noun. The forms, irregular ones included, come in the order of the
+// morpho indices, without duplicates; a deponent's are active.
+func (l *Lemmatizer) FormsFor(lemma *Lemma, filter Morphology) []string {
+	return l.formsFor(lemma, filter)
+}
+
+// MergedInflectionTable returns one inflection table for the homonyms
+// of key (sero, sero2…) inflected on the model of the first of them, for
+// display: the cells where they agree are shown once, and those where
+// they differ keep the forms of each. Homonyms on other models are left
+// out. It returns nil when key names no inflected lemma.
+func (l *Lemmatizer) MergedInflectionTable(key string) *MergedInflectionTable {
+	return l.mergedInflectionTable(key)
+}
+
+// InflectStem inflects a word missing from the lexicon, given by its
+// canonical form with or without quantity marks ("blurgus"), on the named
+// model, as if lemmes.la listed it: "decline blurgus like lupus". Nothing
+// is added to the lexicon. It fails for an unknown model.
+func (l *Lemmatizer) InflectStem(stem, modelName string) (*InflectionTable, error) {
+	return l.inflectStem(stem, modelName)
+}
+
+// InflectionByFeatures returns the cells of the inflection table of lemma
+// whose Universal Dependencies features include feats, a UD feature
+// string such as "Mood=Ind|Number=Sing|Person=1|Tense=Pres" where a
+// feature may list alternatives ("Case=Nom,Acc"). Features and values
+// that UDFeatures never produces are an error.
+func (l *Lemmatizer) InflectionByFeatures(lemma *Lemma, feats string) (map[int][]string, error) {
+	return l.inflectionByFeatures(lemma, feats)
+}
+
+// ModelParadigm returns the paradigm of the named model independently of
+// any lemma: one slot per morpho cell, in morpho order, with its label and
+// endings. It returns nil for an unknown model.
+func (l *Lemmatizer) ModelParadigm(name string) []ParadigmSlot {
+	return l.modelParadigm(name)
+}
+
+// SyncreticForms returns the forms of lemma that realize more than one
+// morphological cell, each mapped to the sorted morpho indices it covers.
+func (l *Lemmatizer) SyncreticForms(lemma *Lemma) map[string][]int {
+	return l.syncreticForms(lemma)
+}
+
+// LexiconCitation returns lemma as dictionaries head it, the long vowels
+// marked, in the style set by SetCitationStyle: the principal parts of a
+// verb, the nominative and genitive and the gender of a noun, the
+// nominatives or the genitive of an adjective, e.g. "amō, āvī, ātum, āre"
+// or "homō, inis, m.". The parts the inflection lacks are left out;
+// the other lemmas are cited by their canonical form.
+func (l *Lemmatizer) LexiconCitation(lemma *Lemma) string {
+	return l.lexiconCitation(lemma)
 }
 
 // addDesinence inserts a desinence into the global desinences map.
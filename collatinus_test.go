@@ -1,12 +1,80 @@
 package collatinus
 
 import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 )
 
 const dataDir = "data"
 
+// copyDataDir returns a copy of the data directory in a temporary
+// directory: the files named in overrides have the given contents, and
+// the others link to the originals.
+func copyDataDir(t *testing.T, overrides map[string]string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, e := range entries {
+		if _, ok := overrides[e.Name()]; ok {
+			continue
+		}
+		src, err := filepath.Abs(filepath.Join(dataDir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(src, filepath.Join(dir, e.Name())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, text := range overrides {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// dataFileNames returns the names of the files of the data directory,
+// not of its subdirectories.
+func dataFileNames(t *testing.T) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// readDataFile returns the contents of the data file name.
+func readDataFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dataDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
 func TestNew(t *testing.T) {
 	l, err := New(dataDir)
 	if err != nil {
@@ -26,6 +94,16 @@ func TestMorpho(t *testing.T) {
 	if got != "nominatif singulier" {
 		t.Errorf("Morpho(1) = %q, want %q", got, "nominatif singulier")
 	}
+
+	indices, descs := l.MorphoIndices(), l.AllMorphos()
+	if len(indices) != len(l.morphos)-1 || len(descs) != len(indices) {
+		t.Fatalf("%d indices and %d descriptions for %d morphos", len(indices), len(descs), len(l.morphos)-1)
+	}
+	for i, mn := range indices {
+		if mn != i+1 || descs[i] != l.Morpho(mn) {
+			t.Fatalf("indices[%d] = %d, description %q", i, mn, descs[i])
+		}
+	}
 }
 
 func TestLemmaTranslation(t *testing.T) {
@@ -40,6 +118,77 @@ func TestLemmaTranslation(t *testing.T) {
 	} else {
 		t.Logf("puella (fr) = %q", tr)
 	}
+	if langs := lemma.TranslationLanguages(); !slices.Contains(langs, "en") || !slices.IsSorted(langs) {
+		t.Errorf("puella.TranslationLanguages() = %v, want sorted codes with en", langs)
+	}
+	if langs := l.Lemma("Adriaticus").TranslationLanguages(); len(langs) != 0 {
+		t.Errorf("Adriaticus.TranslationLanguages() = %v, want none", langs)
+	}
+}
+
+func TestNewLanguages(t *testing.T) {
+	l, err := New(dataDir, WithLanguages("fr", "en"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if langs := slices.Sorted(maps.Keys(l.Languages())); !slices.Equal(langs, []string{"en", "fr"}) {
+		t.Errorf("Languages() = %v, want [en fr]", langs)
+	}
+	if langs := l.Lemma("puella").TranslationLanguages(); !slices.Equal(langs, []string{"en", "fr"}) {
+		t.Errorf("puella.TranslationLanguages() = %v, want [en fr]", langs)
+	}
+
+	l, err = New(dataDir, WithoutTranslations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Languages()) != 0 || l.Lemma("puella").Translation("fr") != "" {
+		t.Errorf("WithoutTranslations: languages %v", l.Languages())
+	}
+	if len(l.LemmatizeWord("puellae", false)) == 0 {
+		t.Error("WithoutTranslations: puellae not lemmatized")
+	}
+}
+
+func TestLemmasWithPrefix(t *testing.T) {
+	l, _ := New(dataDir)
+	got := l.LemmasWithPrefix("rom", 0)
+	if len(got) == 0 || got[0].Key != "Roma" {
+		t.Fatalf("LemmasWithPrefix(rom) = %v, want Roma first", got)
+	}
+	if !slices.IsSortedFunc(got, func(a, b *Lemma) int { return strings.Compare(a.Key, b.Key) }) {
+		t.Error("LemmasWithPrefix(rom) not sorted by key")
+	}
+	var want int
+	for lemma := range l.Lemmas() {
+		if strings.HasPrefix(lemma.Key, "rom") || strings.HasPrefix(lemma.Key, "Rom") {
+			want++
+		}
+	}
+	if len(got) != want {
+		t.Errorf("LemmasWithPrefix(rom): %d lemmas, want %d", len(got), want)
+	}
+	// normalized as a key
+	if got := l.LemmasWithPrefix("vir", 3); len(got) != 3 || !strings.HasPrefix(strings.ToLower(got[0].Key), "uir") {
+		t.Errorf("LemmasWithPrefix(vir, 3) = %v", got)
+	}
+	if got := l.LemmasWithPrefix("xyzzy", 10); len(got) != 0 {
+		t.Errorf("LemmasWithPrefix(xyzzy) = %v", got)
+	}
+}
+
+func TestRadicalNumbers(t *testing.T) {
+	l, _ := New(dataDir)
+	amo := l.Lemma("amo")
+	nums := amo.RadicalNumbers()
+	if !slices.IsSorted(nums) || !slices.Contains(nums, 1) || !slices.Contains(nums, 2) {
+		t.Errorf("amo.RadicalNumbers() = %v, want sorted with 1 and 2", nums)
+	}
+	for _, n := range nums {
+		if len(amo.RadicalsAt(n)) == 0 {
+			t.Errorf("amo: no radical %d", n)
+		}
+	}
 }
 
 func TestLemmatizeWordPuellae(t *testing.T) {
@@ -97,6 +246,86 @@ func TestLemmatizeWordAmat(t *testing.T) {
 	t.Logf("amat analyses for 'amo': %v", result[foundLemma])
 }
 
+func TestInflectionTableCache(t *testing.T) {
+	l, _ := New(dataDir)
+	amo := l.Lemma("amo")
+	table := l.InflectionTable(amo)
+	if l.InflectionTable(amo) != table {
+		t.Error("second InflectionTable(amo) not cached")
+	}
+	l.InvalidateInflectionCache()
+	fresh := l.InflectionTable(amo)
+	if fresh == table {
+		t.Error("InflectionTable(amo) cached after InvalidateInflectionCache")
+	}
+	if !maps.EqualFunc(fresh.Cells, table.Cells, slices.Equal) {
+		t.Error("InflectionTable(amo) differs after InvalidateInflectionCache")
+	}
+	if l.InflectionTable(nil) != nil {
+		t.Error("InflectionTable(nil) != nil")
+	}
+}
+
+func TestExportInflectionCSV(t *testing.T) {
+	l, _ := New(dataDir)
+	var buf bytes.Buffer
+	if err := l.ExportInflectionCSV(l.InflectionTable(l.Lemma("rosa")), &buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 14 {
+		t.Fatalf("%d rows, want 14: %q", len(rows), rows)
+	}
+	if !slices.Equal(rows[0], []string{"lemma", "rosa", "rŏsa"}) || !slices.Equal(rows[1], []string{"index", "morpho", "forms"}) {
+		t.Errorf("head rows %q", rows[:2])
+	}
+	if !slices.Equal(rows[11], []string{"10", "génitif pluriel", "rŏsārŭm"}) {
+		t.Errorf("row of index 10: %q", rows[11])
+	}
+
+	buf.Reset()
+	table := &InflectionTable{Lemma: l.Lemma("rosa"), Cells: map[int][]string{4: {"rŏsāe", "rŏsāī"}}}
+	l.ExportInflectionCSV(table, &buf)
+	if want := "lemma,rosa,rŏsa\nindex,morpho,forms\n4,génitif singulier,\"rŏsāe, rŏsāī\"\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestFormsFor(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, tt := range []struct {
+		key    string
+		filter Morphology
+		want   []string
+	}{
+		{"rosa", Morphology{Case: CaseGenitive, Number: NumberPlural}, []string{"rŏsārŭm"}},
+		// irregular
+		{"sum", Morphology{Person: 3, Number: NumberPlural, Tense: TensePresent, Mood: MoodIndicative}, []string{"sūnt"}},
+		// deponent, active
+		{"imitor", Morphology{Person: 1, Number: NumberSingular, Tense: TensePresent,
+			Mood: MoodIndicative, Voice: VoiceActive}, []string{"ĭmĭtŏr"}},
+		{"rosa", Morphology{Mood: MoodParticiple}, nil},
+	} {
+		if got := l.FormsFor(l.Lemma(tt.key), tt.filter); !slices.Equal(got, tt.want) {
+			t.Errorf("FormsFor(%s, %+v) = %v, want %v", tt.key, tt.filter, got, tt.want)
+		}
+	}
+
+	var all []string
+	for _, forms := range l.InflectionTable(l.Lemma("rosa")).Cells {
+		all = append(all, forms...)
+	}
+	slices.Sort(all)
+	got := l.FormsFor(l.Lemma("rosa"), Morphology{})
+	slices.Sort(got)
+	if !slices.Equal(got, slices.Compact(all)) {
+		t.Errorf("FormsFor(rosa, {}) = %v, want all of %v", got, all)
+	}
+}
+
 func TestInflectionTableLupus(t *testing.T) {
 	l, _ := New(dataDir)
 	lemma := l.Lemma("lupus")
@@ -119,6 +348,90 @@ func TestInflectionTableLupus(t *testing.T) {
 	}
 }
 
+func TestThirdDeclensionStems(t *testing.T) {
+	l, _ := New(dataDir)
+	tests := []struct {
+		key        string
+		accPl, gen []string
+	}{
+		// i-stem: accusative plural in -ēs and -īs, genitive plural in -ium
+		{"ciuis", []string{"cīvīs", "cīvēs"}, []string{"cīvĭŭm"}},
+		{"urbs", []string{"ūrbīs", "ūrbēs"}, []string{"ūrbĭŭm"}},
+		// consonant stem: -ēs and -um only
+		{"miles", []string{"mīlĭtēs"}, []string{"mīlĭtŭm"}},
+	}
+	for _, tt := range tests {
+		cells := l.InflectionTable(l.Lemma(tt.key)).Cells
+		if !slices.Equal(cells[9], tt.accPl) {
+			t.Errorf("%s accusative plural = %v, want %v", tt.key, cells[9], tt.accPl)
+		}
+		if !slices.Equal(cells[10], tt.gen) {
+			t.Errorf("%s genitive plural = %v, want %v", tt.key, cells[10], tt.gen)
+		}
+	}
+
+	hasMorpho := func(form, key string, mn int) bool {
+		for lemma, analyses := range l.LemmatizeWord(form, false) {
+			for _, a := range analyses {
+				if lemma.Key == key && a.MorphoIndex == mn {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, tt := range []struct {
+		form, key string
+		mn        int
+		want      bool
+	}{
+		{"ciuium", "ciuis", 10, true},
+		{"ciuis", "ciuis", 9, true},
+		{"ciues", "ciuis", 9, true},
+		{"militum", "miles", 10, true},
+		{"militium", "miles", 10, false},
+		{"militis", "miles", 9, false},
+	} {
+		if got := hasMorpho(tt.form, tt.key, tt.mn); got != tt.want {
+			t.Errorf("%s as %s morpho %d: got %v, want %v", tt.form, tt.key, tt.mn, got, tt.want)
+		}
+	}
+}
+
+func TestVocative(t *testing.T) {
+	l, _ := New(dataDir)
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"lupus", []string{"lŭpĕ"}},
+		{"filius", []string{"fīlī"}},
+		{"Vergilius", []string{"Vērgĭlī", "Vīrgĭlī"}},
+		// deus and puer keep the nominative
+		{"deus", []string{"dĕŭs"}},
+		{"puer", []string{"pŭĕr"}},
+	}
+	for _, tt := range tests {
+		if got := l.InflectionTable(l.Lemma(tt.key)).Cells[2]; !slices.Equal(got, tt.want) {
+			t.Errorf("%s vocative = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	for form, key := range map[string]string{"lupe": "lupus", "fili": "filius"} {
+		found := false
+		for lemma, analyses := range l.LemmatizeWord(form, false) {
+			for _, a := range analyses {
+				if lemma.Key == key && a.MorphoIndex == 2 {
+					found = true
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: no vocative analysis of %s", form, key)
+		}
+	}
+}
+
 func TestLemmatizeWordNec(t *testing.T) {
 	l, _ := New(dataDir)
 	result := l.LemmatizeWord("nec", false)
@@ -149,6 +462,18 @@ func TestEncliticStripping(t *testing.T) {
 			t.Logf("  %s: %v", lemma.Grq, analyses)
 		}
 	}
+	for _, a := range result[foundLemma] {
+		if a.Enclitic != "que" {
+			t.Errorf("populusque: Enclitic = %q, want que", a.Enclitic)
+		}
+	}
+	for lemma, analyses := range l.LemmatizeWord("populus", false) {
+		for _, a := range analyses {
+			if a.Enclitic != "" {
+				t.Errorf("populus (%s): Enclitic = %q, want none", lemma.Key, a.Enclitic)
+			}
+		}
+	}
 }
 
 func TestNormalize(t *testing.T) {
@@ -188,6 +513,34 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
+func TestQuantityCompatible(t *testing.T) {
+	for _, tt := range []struct {
+		user, marked string
+		want         bool
+	}{
+		{"rosa", "rŏsā", true},
+		{"rosā", "rŏsā", true},
+		{"rosă", "rŏsā", false},
+		{"rosā", "rŏsă", false},
+		{"rosa", "rŏsă", true},
+		{"rŏsa", "rŏsă", true},
+		{"rōsa", "rŏsă", false},
+		{"rosā", "rosa", true}, // unmarked in the form
+		{"rosă", "rosa", true},
+		{"rosa\u0304", "rŏsā", true}, // combining macron
+		{"rosa\u0306", "rŏsā", false},
+		{"ablŭo", "ā̆blŭo", true}, // common vowel
+		{"ăbluo", "ā̆blŭo", true},
+		{"ā̆bluo", "ăblŭo", true},
+		{"rosas", "rŏsā", false},
+		{"Rosa", "rŏsā", false},
+	} {
+		if got := QuantityCompatible(tt.user, tt.marked); got != tt.want {
+			t.Errorf("QuantityCompatible(%q, %q) = %v, want %v", tt.user, tt.marked, got, tt.want)
+		}
+	}
+}
+
 func TestListI(t *testing.T) {
 	tests := []struct {
 		in   string
@@ -211,3 +564,2350 @@ func TestListI(t *testing.T) {
 		}
 	}
 }
+
+func TestSyncreticFormsPuella(t *testing.T) {
+	l, _ := New(dataDir)
+	lemma := l.Lemma("puella")
+	if lemma == nil {
+		t.Fatal("Lemma('puella') is nil")
+	}
+	forms := l.SyncreticForms(lemma)
+	var got []int
+	for f, mns := range forms {
+		if Atone(f) == "puellae" {
+			got = mns
+		}
+	}
+	want := []int{4, 5, 7, 8}
+	if len(got) != len(want) {
+		t.Fatalf("SyncreticForms(puella)[puellae] = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("SyncreticForms(puella)[puellae] = %v, want %v", got, want)
+			break
+		}
+	}
+	for f, mns := range forms {
+		if len(mns) < 2 {
+			t.Errorf("form %q listed with a single morpho %v", f, mns)
+		}
+	}
+}
+
+func TestLigatureInitialProperNouns(t *testing.T) {
+	l, _ := New(dataDir)
+	tests := []struct {
+		form          string
+		sentenceStart bool
+		want          string // lemma.Gr
+	}{
+		{"Œdipus", false, "Oedipus"},
+		{"œdipus", false, "Oedipus"},
+		{"OEdipus", false, "Oedipus"},
+		{"Æneas", false, "Aeneas"},
+		{"Ænean", true, "Aeneas"},
+		{"ÆNEAS", false, "Aeneas"},
+		{"ÆNEAS", true, "Aeneas"},
+	}
+	for _, tt := range tests {
+		result := l.LemmatizeWord(tt.form, tt.sentenceStart)
+		found := false
+		for lemma := range result {
+			if lemma.Gr == tt.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("LemmatizeWord(%q, %v) did not find lemma %q", tt.form, tt.sentenceStart, tt.want)
+		}
+	}
+}
+
+func TestInflectionPrimaryFormFirst(t *testing.T) {
+	l, _ := New(dataDir)
+	tests := []struct {
+		key   string
+		mn    int
+		first string
+	}{
+		{"Aeneas", 1, "Āenēās"},
+		{"Aeneas", 3, "Āenēān"},
+		{"is", 19, "ĕī"},
+	}
+	for _, tt := range tests {
+		lemma := l.Lemma(tt.key)
+		if lemma == nil {
+			t.Fatalf("Lemma(%q) is nil", tt.key)
+		}
+		forms := l.InflectionTable(lemma).Cells[tt.mn]
+		if len(forms) < 2 {
+			t.Fatalf("%s cell %d = %v, want several forms", tt.key, tt.mn, forms)
+		}
+		if forms[0] != tt.first {
+			t.Errorf("%s cell %d = %v, want %q first", tt.key, tt.mn, forms, tt.first)
+		}
+	}
+
+	for _, d := range l.Lemma("Aeneas").Model().DesinencesAt(1) {
+		if d.Variant != (d.Grq != "ās") {
+			t.Errorf("aeneas desinence %q: Variant = %v", d.Grq, d.Variant)
+		}
+	}
+}
+
+func TestSegmentations(t *testing.T) {
+	l, _ := New(dataDir)
+	segs := l.Segmentations("puellae")
+	found := false
+	for _, s := range segs {
+		if s.Stem+s.Ending != "puellae" {
+			t.Errorf("segmentation %q+%q does not rebuild the form", s.Stem, s.Ending)
+		}
+		if len(s.Radicals) == 0 || len(s.Desinences) == 0 {
+			t.Errorf("segmentation %q+%q has no radical or no desinence", s.Stem, s.Ending)
+		}
+		if s.Stem == "puell" && s.Ending == "ae" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Segmentations('puellae') lacks puell+ae; got %d cuts", len(segs))
+	}
+}
+
+func TestCompoundVerbFallback(t *testing.T) {
+	l, _ := New(dataDir)
+	if got := l.LemmatizeWord("pertranseo", false); len(got) != 0 {
+		t.Fatalf("compound fallback applied without the Prefixes option: %d lemmas", len(got))
+	}
+
+	l.SetLemmatizeOptions(LemmatizeOptions{Prefixes: true})
+	tests := []struct {
+		form, prefix, base string
+	}{
+		{"pertranseo", "per", "transeo"},
+		{"superimpono", "super", "impono"},
+		{"praecognosco", "prae", "cognosco"},
+	}
+	for _, tt := range tests {
+		result := l.LemmatizeWord(tt.form, false)
+		found := false
+		for lemma, analyses := range result {
+			if lemma.Gr != tt.base {
+				continue
+			}
+			found = true
+			for _, a := range analyses {
+				if a.Prefix != tt.prefix {
+					t.Errorf("%s: Prefix = %q, want %q", tt.form, a.Prefix, tt.prefix)
+				}
+				if Atone(a.FormWithMarks) != tt.form {
+					t.Errorf("%s: FormWithMarks = %q", tt.form, a.FormWithMarks)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("LemmatizeWord(%q) did not find %q", tt.form, tt.base)
+		}
+	}
+
+	// Known words are never decomposed.
+	for lemma, analyses := range l.LemmatizeWord("perdoceo", false) {
+		for _, a := range analyses {
+			if a.Prefix != "" {
+				t.Errorf("perdoceo analysed as compound of %s", lemma.Grq)
+			}
+		}
+	}
+}
+
+func TestFormIndexMatchesDynamicPath(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the form index takes several seconds")
+	}
+	l, _ := New(dataDir)
+	words := []string{"puellae", "amat", "lupe", "filii", "dominorum", "populusque", "inanit", "Romae"}
+	want := make(map[string]map[*Lemma][]Analysis)
+	for _, w := range words {
+		want[w] = l.LemmatizeWord(w, false)
+	}
+	l.BuildFormIndex()
+	for _, w := range words {
+		got := l.LemmatizeWord(w, false)
+		if len(got) != len(want[w]) {
+			t.Errorf("%s: %d lemmas with the index, %d without", w, len(got), len(want[w]))
+			continue
+		}
+		for lemma, analyses := range want[w] {
+			if len(got[lemma]) != len(analyses) {
+				t.Errorf("%s/%s: %d analyses with the index, %d without", w, lemma.Key, len(got[lemma]), len(analyses))
+			}
+		}
+	}
+}
+
+func TestWriteLoadFormIndex(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the form index takes several seconds")
+	}
+	l, _ := New(dataDir)
+	var buf bytes.Buffer
+	if err := l.WriteFormIndex(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	l2, _ := New(dataDir)
+	if err := l2.LoadFormIndex(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if len(l2.index) != len(l.index) {
+		t.Errorf("loaded %d keys, wrote %d", len(l2.index), len(l.index))
+	}
+	for _, w := range []string{"puellae", "amat", "filii"} {
+		got, want := l2.LemmatizeWord(w, false), l.LemmatizeWord(w, false)
+		for lemma, analyses := range want {
+			g := l2.LemmaByKey(lemma.Key)
+			if !slices.Equal(got[g], analyses) {
+				t.Errorf("%s/%s: loaded %v, built %v", w, lemma.Key, got[g], analyses)
+			}
+		}
+	}
+
+	// other data: the index is refused
+	l3, _ := New(dataDir)
+	l3.LemmaByKey("lupus").AddTranslation("xx", "wolf")
+	l3.fingerprint = l3.computeFingerprint()
+	if err := l3.LoadFormIndex(bytes.NewReader(data)); err == nil || l3.index != nil {
+		t.Errorf("index loaded despite another fingerprint (err %v)", err)
+	}
+	if err := l3.LoadFormIndex(strings.NewReader("not gzip")); err == nil {
+		t.Error("garbage: want an error")
+	}
+}
+
+var benchWords = []string{"puellae", "amat", "Gallia", "est", "omnis", "diuisa", "in", "partes", "tres", "dominorum"}
+
+func BenchmarkLemmatizeWord(b *testing.B) {
+	l, _ := New(dataDir)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.LemmatizeWord(benchWords[i%len(benchWords)], false)
+	}
+}
+
+func BenchmarkLemmatizeWordFormIndex(b *testing.B) {
+	l, _ := New(dataDir)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	l.BuildFormIndex()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.LemmatizeWord(benchWords[i%len(benchWords)], false)
+	}
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/(1<<20), "index-MB")
+}
+
+func BenchmarkInflectionTable(b *testing.B) {
+	l, _ := New(dataDir)
+	amo := l.Lemma("amo")
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			l.InvalidateInflectionCache()
+			l.InflectionTable(amo)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			l.InflectionTable(amo)
+		}
+	})
+}
+
+func TestNumerals(t *testing.T) {
+	l, _ := New(dataDir)
+	cardinals := []string{"unus", "duo", "tres", "quattuor", "quinque", "sex", "septem", "octo", "novem", "decem"}
+	for i, key := range cardinals {
+		lemma := l.Lemma(key)
+		if lemma == nil {
+			t.Errorf("Lemma(%q) is nil", key)
+			continue
+		}
+		if lemma.POS != POSNumeral {
+			t.Errorf("%s: POS = %c, want %c", key, lemma.POS, POSNumeral)
+		}
+		if v, ok := lemma.NumericValue(); !ok || v != i+1 {
+			t.Errorf("%s: NumericValue() = %d, %v; want %d", key, v, ok, i+1)
+		}
+	}
+
+	found := false
+	for lemma := range l.LemmatizeWord("tertiam", false) {
+		if v, ok := lemma.NumericValue(); ok && v == 3 && lemma.POS == POSNumeral {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("LemmatizeWord('tertiam') did not find the ordinal tertius")
+	}
+
+	if _, ok := l.Lemma("lupus").NumericValue(); ok {
+		t.Error("lupus has a numeric value")
+	}
+}
+
+func TestSource(t *testing.T) {
+	l, _ := New(dataDir)
+
+	file, line := l.Lemma("abdo").Source()
+	if file != filepath.Join(dataDir, "lemmes.la") || line != 39 {
+		t.Errorf("abdo.Source() = %s:%d, want lemmes.la:39", file, line)
+	}
+
+	file, line = l.Lemma("lupus").Model().Source()
+	if file != filepath.Join(dataDir, "modeles.la") || line != 132 {
+		t.Errorf("lupus model Source() = %s:%d, want modeles.la:132", file, line)
+	}
+
+	for _, irr := range l.irregs["boum"] {
+		file, line = irr.Source()
+		if file != filepath.Join(dataDir, "irregs.la") || line != 30 {
+			t.Errorf("boum Source() = %s:%d, want irregs.la:30", file, line)
+		}
+	}
+}
+
+func TestFindLemma(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, q := range []string{"Caesar", "caesar", "cæsar", "Cǣsar", "CAESAR"} {
+		found := false
+		for _, lemma := range l.FindLemma(q) {
+			if lemma.Key == "Caesar" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("FindLemma(%q) did not find Caesar", q)
+		}
+	}
+
+	keys := make(map[string]bool)
+	for _, lemma := range l.FindLemma("caesius") {
+		keys[lemma.Key] = true
+	}
+	if !keys["caesius"] || !keys["Caesius2"] {
+		t.Errorf("FindLemma('caesius') = %v, want caesius and Caesius2", keys)
+	}
+
+	if got := l.FindLemma("xyzzy"); len(got) != 0 {
+		t.Errorf("FindLemma('xyzzy') = %d lemmas, want none", len(got))
+	}
+}
+
+func TestExplain(t *testing.T) {
+	l, _ := New(dataDir)
+	tests := []struct {
+		form, lang, want string
+	}{
+		{"puellae", "en", "puellae is the genitive/dative singular or nominative/vocative plural of puella (girl, (female) child/daughter), a 1st-declension feminine noun."},
+		{"Caesaris", "fr", "Caesaris : génitif singulier de Caesar (César, empereur), nom masculin de la 3e déclinaison."},
+		{"amat", "fr", "amat : 3ème singulier indicatif présent actif d'amo (aimer), verbe de la 1re conjugaison."},
+	}
+	for _, tt := range tests {
+		got := l.Explain(tt.form, tt.lang)
+		if len(got) == 0 || got[0] != tt.want {
+			t.Errorf("Explain(%q, %q) = %q, want %q first", tt.form, tt.lang, got, tt.want)
+		}
+	}
+
+	for _, s := range l.Explain("est", "en") {
+		if !strings.HasSuffix(s, "an irregular verb.") {
+			t.Errorf("est: %q", s)
+		}
+	}
+	if got := l.Explain("xyzzy", "en"); len(got) != 0 {
+		t.Errorf("unknown form: %q", got)
+	}
+}
+
+func TestLemmatizeWordRanked(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, tt := range []struct {
+		form, first string
+		penalty     float64
+	}{
+		{"est", "sum", 1},
+		{"populusque", "populus", rankPenalty},
+		{"roma", "Roma", rankPenalty},
+		{"mecum", "ego", rankPenalty},
+	} {
+		ranked := l.LemmatizeWordRanked(tt.form, false)
+		if len(ranked) == 0 || ranked[0].Lemma.Key != tt.first {
+			t.Errorf("%s: ranked %+v, want %s first", tt.form, ranked, tt.first)
+			continue
+		}
+		if want := float64(ranked[0].Lemma.NbOcc) * tt.penalty; ranked[0].Score != want {
+			t.Errorf("%s: score %v, want %v", tt.form, ranked[0].Score, want)
+		}
+		if len(ranked) != len(l.LemmatizeWord(tt.form, false)) {
+			t.Errorf("%s: %d lemmas ranked", tt.form, len(ranked))
+		}
+		if !slices.IsSortedFunc(ranked, func(a, b RankedAnalysis) int {
+			if a.Score != b.Score {
+				return cmp.Compare(b.Score, a.Score)
+			}
+			return strings.Compare(a.Lemma.Key, b.Lemma.Key)
+		}) {
+			t.Errorf("%s: not sorted: %+v", tt.form, ranked)
+		}
+	}
+	if ranked := l.LemmatizeWordRanked("xyzzy", false); len(ranked) != 0 {
+		t.Errorf("xyzzy: %+v", ranked)
+	}
+}
+
+func TestEncliticCum(t *testing.T) {
+	l, _ := New(dataDir)
+	for form, pronoun := range map[string]string{
+		"mecum": "ego", "tecum": "tu", "secum": "se", "nobiscum": "nos", "uobiscum": "uos",
+	} {
+		var ablative, cum bool
+		for lemma, analyses := range l.LemmatizeWord(form, false) {
+			switch {
+			case lemma.Key == pronoun:
+				for _, a := range analyses {
+					if !strings.Contains(a.MorphoDescription, "ablatif") {
+						t.Errorf("%s: %s analysed as %s", form, pronoun, a.MorphoDescription)
+					}
+					if a.Enclitic != "cum" {
+						t.Errorf("%s: Enclitic = %q, want cum", form, a.Enclitic)
+					}
+				}
+				ablative = len(analyses) > 0
+			case lemma.Key == "cum":
+				cum = lemma.POS == POSPreposition
+			}
+		}
+		if !ablative || !cum {
+			t.Errorf("%s: %s ablative found: %v, preposition cum found: %v", form, pronoun, ablative, cum)
+		}
+	}
+
+	// relative pronouns keep their contracted lemma only
+	for lemma := range l.LemmatizeWord("quibuscum", false) {
+		if lemma.Key == "cum" {
+			t.Error("quibuscum: split off cum")
+		}
+	}
+}
+
+func TestDiffLemmatize(t *testing.T) {
+	l, _ := New(dataDir)
+	words := []string{"rosa", "est", "lupi", "xyzzy", "cum"}
+	if d := l.DiffLemmatize(l, words); len(d) != 0 {
+		t.Errorf("DiffLemmatize with itself = %v, want none", d)
+	}
+
+	other, _ := New(dataDir)
+	other.SetLemmatizeOptions(LemmatizeOptions{MinFrequency: 1000})
+	diffs := l.DiffLemmatize(other, words)
+	if len(diffs) == 0 {
+		t.Fatal("no diff with MinFrequency set")
+	}
+	for _, d := range diffs {
+		if len(d.Added) != 0 {
+			t.Errorf("%s: added %v", d.Word, d.Added)
+		}
+		for _, a := range d.Removed {
+			if other.Lemma(a.LemmaKey).NbOcc >= 1000 {
+				t.Errorf("%s: removed an analysis of frequent %s", d.Word, a.LemmaKey)
+			}
+		}
+	}
+}
+
+func TestAttested(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, c := range []struct {
+		form, lemma string
+		want        bool
+	}{
+		{"bobus", "bos", true},
+		{"aliud", "alius", true},
+		{"bovem", "bos", false},
+		{"amat", "amo", false},
+	} {
+		analyses := l.LemmatizeWord(c.form, false)[l.Lemma(c.lemma)]
+		if len(analyses) == 0 {
+			t.Fatalf("%s is not analysed as %s", c.form, c.lemma)
+		}
+		for _, a := range analyses {
+			if a.Attested != c.want {
+				t.Errorf("%s (%s): Attested = %v, want %v", c.form, a.MorphoDescription, a.Attested, c.want)
+			}
+		}
+	}
+}
+
+func TestPreferLongStems(t *testing.T) {
+	l, _ := New(dataDir)
+	// dum is the conjunction, not d-um from the far more frequent do.
+	mm := l.LemmatizeWord("dum", false)
+	if first := l.RankLemmas(mm)[0]; first.Key != "do" {
+		t.Errorf("default first lemma of dum = %s, want do", first.Key)
+	}
+	l.SetLemmatizeOptions(LemmatizeOptions{PreferLongStems: true})
+	long := l.LemmatizeWord("dum", false)
+	if len(long) != len(mm) {
+		t.Errorf("PreferLongStems kept %d lemmas of %d", len(long), len(mm))
+	}
+	ranked := l.RankLemmas(long)
+	if ranked[0].Key != "dum" {
+		t.Errorf("first lemma of dum = %s, want dum", ranked[0].Key)
+	}
+	for _, lemma := range ranked {
+		as := long[lemma]
+		for i := 1; i < len(as); i++ {
+			if as[i].StemLength > as[i-1].StemLength {
+				t.Errorf("%s: stem %d ranked after %d", lemma.Key, as[i].StemLength, as[i-1].StemLength)
+			}
+		}
+	}
+}
+
+func TestIsValidForm(t *testing.T) {
+	l, _ := New(dataDir)
+	// direct, double-i, enclitic, capitalized and assimilated forms
+	for _, form := range []string{"puellae", "filii", "arma", "uirumque", "roma", "Corelli", "adfero"} {
+		if !l.IsValidForm(form) {
+			t.Errorf("IsValidForm(%q) = false, want true", form)
+		}
+	}
+	for _, form := range []string{"", "xyzzy", "puellaexx"} {
+		if l.IsValidForm(form) {
+			t.Errorf("IsValidForm(%q) = true, want false", form)
+		}
+	}
+	for _, form := range []string{"puellae", "fili", "uirumque", "xyzzy"} {
+		if got, want := l.IsValidForm(form), len(l.LemmatizeWord(form, false)) > 0; got != want {
+			t.Errorf("IsValidForm(%q) = %v, LemmatizeWord found %v", form, got, want)
+		}
+	}
+}
+
+func TestCoverageVocabulary(t *testing.T) {
+	l, _ := New(dataDir)
+	// 12 words: puella 3, amo 2, rosa 2, et, nauta, ambulo, and xyzzy.
+	text := "Puella rosam amat. Puella rosas amat. Puella et nauta ambulant. Xyzzy."
+	for _, tc := range []struct {
+		pct  float64
+		want []string
+	}{
+		{0, nil},
+		{25, []string{"puella"}},
+		{30, []string{"puella", "amo"}},
+		{50, []string{"puella", "amo", "rosa"}},
+		{100, []string{"puella", "amo", "rosa", "et", "nauta", "ambulo"}},
+	} {
+		var got []string
+		for _, lemma := range l.CoverageVocabulary(text, tc.pct) {
+			got = append(got, lemma.Key)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("CoverageVocabulary(%v%%) = %v, want %v", tc.pct, got, tc.want)
+		}
+	}
+}
+
+func TestSpacyDoc(t *testing.T) {
+	l, _ := New(dataDir)
+	if got := l.UDFeatures(4); got != "Case=Gen|Number=Sing" {
+		t.Errorf("UDFeatures(4) = %q", got)
+	}
+	// 151: 1ère singulier indicatif futur antérieur actif
+	if got, want := l.UDFeatures(151), "Aspect=Perf|Mood=Ind|Number=Sing|Person=1|Tense=Fut|VerbForm=Fin|Voice=Act"; got != want {
+		t.Errorf("UDFeatures(151) = %q, want %q", got, want)
+	}
+
+	text := "Cæsar  pulcher est, xyzzy.\n"
+	doc := l.SpacyDoc(text)
+	runes := []rune(doc.Text)
+	var words []string
+	var rebuilt strings.Builder
+	for i, tok := range doc.Tokens {
+		word := string(runes[tok.Start:tok.End])
+		words = append(words, word)
+		rebuilt.WriteString(word)
+		next := len(runes)
+		if i+1 < len(doc.Tokens) {
+			next = doc.Tokens[i+1].Start
+		}
+		rebuilt.WriteString(string(runes[tok.End:next]))
+		if next-tok.End > 1 || next > tok.End && runes[tok.End] != ' ' {
+			t.Errorf("gap after %q is not a single space", word)
+		}
+	}
+	if rebuilt.String() != text {
+		t.Errorf("tokens rebuild %q, want %q", rebuilt.String(), text)
+	}
+	want := []string{"Cæsar", " ", "pulcher", "est", ",", "xyzzy", ".", "\n"}
+	if !slices.Equal(words, want) {
+		t.Fatalf("tokens = %q, want %q", words, want)
+	}
+	if tok := doc.Tokens[0]; tok.POS != "PROPN" || tok.Lemma != "Caesar" || !strings.Contains(tok.Morph, "Case=") {
+		t.Errorf("Cæsar = %+v", tok)
+	}
+	if tok := doc.Tokens[4]; tok.POS != "PUNCT" {
+		t.Errorf("comma = %+v", tok)
+	}
+	// The passive forms of a deponent are active, as in MorphoOf.
+	if tok := l.SpacyDoc("hortator").Tokens[0]; tok.Lemma != "hortor" || !strings.Contains(tok.Morph, "Voice=Act") {
+		t.Errorf("hortator = %+v, want the active of hortor", tok)
+	}
+	if tok := doc.Tokens[5]; tok.POS != "X" || tok.Lemma != "xyzzy" {
+		t.Errorf("unknown word = %+v", tok)
+	}
+	if tok := doc.Tokens[7]; tok.POS != "SPACE" {
+		t.Errorf("newline = %+v", tok)
+	}
+
+	res := l.LemmatizeText(text)
+	if len(res) < 2 || text[res[1].Start:res[1].End] != "pulcher" {
+		t.Errorf("offsets of the second token: %+v", res[1])
+	}
+}
+
+func TestLemmatizeReader(t *testing.T) {
+	l, _ := New(dataDir)
+	// Past readerChunk, so that the texts are read in two chunks at least.
+	text := strings.Repeat("Arma virumque cano; Troiae qui primus\nab oris. ", 1600)
+	for _, text := range []string{
+		text,
+		// a first chunk without words
+		strings.Repeat("1234567 89\n", 7000) + "rosa",
+		// a word followed by more than a chunk without words
+		"rosa " + strings.Repeat("12345 ---\n", 8000) + "puella\n",
+	} {
+		want := l.LemmatizeText(text)
+		var got []LemmatizationResult
+		err := l.LemmatizeReader(strings.NewReader(text), func(r LemmatizationResult) error {
+			got = append(got, r)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%d results, want %d", len(got), len(want))
+		}
+		for i := range want {
+			g, w := got[i], want[i]
+			if g.Token != w.Token || g.Start != w.Start || g.End != w.End ||
+				g.SentenceStart != w.SentenceStart || len(g.Analyses) != len(w.Analyses) {
+				t.Fatalf("result %d = %q %d-%d %v, want %q %d-%d %v", i,
+					g.Token, g.Start, g.End, g.SentenceStart,
+					w.Token, w.Start, w.End, w.SentenceStart)
+			}
+		}
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := l.LemmatizeReader(strings.NewReader(text), func(LemmatizationResult) error {
+		if n++; n == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 3 {
+		t.Errorf("err = %v after %d results, want stop after 3", err, n)
+	}
+}
+
+func TestMacronize(t *testing.T) {
+	l, _ := New(dataDir)
+	for text, want := range map[string]string{
+		// rosa is short as a nominative and long as an ablative.
+		"Rosa!":                 "Rosa!",
+		"ROSAS, dominae":        "ROSĀS, dominae",
+		"virumque cano":         "virumque canō",
+		"fato profugus":         "fātō profugus",
+		"xyzzy, Aeneas":         "xyzzy, Aenēās",
+		"Laviniaque venit\n...": "Lāvīniaque venit\n...",
+	} {
+		if got := l.Macronize(text); got != want {
+			t.Errorf("Macronize(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestExportCoNLLU(t *testing.T) {
+	l, _ := New(dataDir)
+	var buf bytes.Buffer
+	if err := l.ExportCoNLLU(l.LemmatizeText("Arma uirumque cano. Xyzzy est!"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	sentences := strings.Split(strings.TrimSuffix(buf.String(), "\n\n"), "\n\n")
+	if len(sentences) != 2 {
+		t.Fatalf("ExportCoNLLU: %d sentences, want 2:\n%s", len(sentences), buf.String())
+	}
+	rows := strings.Split(sentences[0], "\n")
+	if rows[0] != "# sent_id = 1" || len(rows) != 4 {
+		t.Fatalf("first sentence:\n%s", sentences[0])
+	}
+	if cols := strings.Split(rows[2], "\t"); len(cols) != 10 || cols[0] != "2" || cols[2] != "vir" ||
+		cols[3] != "NOUN" || cols[5] != "Case=Acc|Number=Sing" {
+		t.Errorf("uirumque: %q", cols)
+	}
+	// est: sum before edo, by NbOcc.
+	for _, want := range []string{
+		"1\tXyzzy\tXyzzy\tX\t_\t_\t_\t_\t_\t_",
+		"2\test\tsum\tVERB\t_\t",
+		"\tAltLemmas=edo",
+	} {
+		if !strings.Contains(sentences[1], want) {
+			t.Errorf("second sentence lacks %q:\n%s", want, sentences[1])
+		}
+	}
+}
+
+func TestInflectionByFeatures(t *testing.T) {
+	l, _ := New(dataDir)
+	amo := l.Lemma("amo")
+	cells, err := l.InflectionByFeatures(amo, "Tense=Pres|Mood=Ind|Person=1|Number=Sing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 121: 1ère singulier indicatif présent actif, and its passive
+	if len(cells) != 2 || !slices.Equal(cells[121], []string{"ămō̆"}) || cells[267] == nil {
+		t.Errorf("amo 1sg present indicative = %v", cells)
+	}
+
+	cells, _ = l.InflectionByFeatures(l.Lemma("lupus"), "Case=Nom,Voc|Number=Plur")
+	if len(cells) != 2 || cells[7] == nil || cells[8] == nil {
+		t.Errorf("lupus nominative/vocative plural = %v", cells)
+	}
+
+	for _, feats := range []string{"Tense=Aor", "Animacy=Anim", "Tense", ""} {
+		if _, err := l.InflectionByFeatures(amo, feats); err == nil {
+			t.Errorf("%q: want an error", feats)
+		}
+	}
+}
+
+func TestLemmasByModel(t *testing.T) {
+	l, _ := New(dataDir)
+	direct := l.LemmasByModel("lupus", false)
+	if len(direct) == 0 {
+		t.Fatal("no lemma for lupus")
+	}
+	for i, lemma := range direct {
+		if lemma.Model().Name != "lupus" {
+			t.Errorf("%s has model %s", lemma.Key, lemma.Model().Name)
+		}
+		if i > 0 && direct[i-1].NbOcc < lemma.NbOcc {
+			t.Errorf("%s (%d) listed after %s (%d)", lemma.Key, lemma.NbOcc, direct[i-1].Key, direct[i-1].NbOcc)
+		}
+	}
+
+	// filius and deus inherit from lupus.
+	derived := l.LemmasByModel("lupus", true)
+	models := make(map[string]bool)
+	for _, lemma := range derived {
+		models[lemma.Model().Name] = true
+	}
+	if len(derived) <= len(direct) || !models["filius"] {
+		t.Errorf("derived lupus lemmas: %d (models %v), direct: %d", len(derived), models, len(direct))
+	}
+
+	if got := l.LemmasByModel("nonexistent", true); len(got) != 0 {
+		t.Errorf("unknown model: got %d lemmas", len(got))
+	}
+}
+
+func TestStemOf(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, c := range []struct{ form, stem, ending string }{
+		{"rosarum", "ros", "arum"},
+		{"Rosārum", "Ros", "ārum"},
+		{"amavit", "amav", "it"},
+		{"jussit", "juss", "it"},
+		{"Musæ", "Mus", "æ"},
+	} {
+		stem, ending, ok := l.StemOf(c.form)
+		if !ok || stem != c.stem || ending != c.ending {
+			t.Errorf("StemOf(%q) = %q, %q, %v; want %q, %q", c.form, stem, ending, ok, c.stem, c.ending)
+		}
+	}
+	if _, _, ok := l.StemOf("xyzzy"); ok {
+		t.Error("StemOf(xyzzy) found a stem")
+	}
+}
+
+func TestNoDuplicateRadicals(t *testing.T) {
+	l, _ := New(dataDir)
+	// Both canonical forms of praepostere yield the stem prāepōstĕr, and
+	// adloquor lists ādlŏcūt twice among its explicit radicals.
+	for _, c := range []struct {
+		key  string
+		num  int
+		want int
+	}{{"praepostere", 0, 1}, {"adloquor", 2, 2}} {
+		if got := len(l.Lemma(c.key).radicals[c.num]); got != c.want {
+			t.Errorf("%s: %d radicals %d, want %d", c.key, got, c.num, c.want)
+		}
+	}
+
+	lemma := newLemma("amo=ămo,ămō|amo|||as, are|1")
+	lemma.model = l.models[lemma.modelName]
+	l.buildRadicals(lemma)
+	if got := len(lemma.radicals[1]); got != 1 {
+		t.Errorf("ămo,ămō: %d radicals 1, want 1", got)
+	}
+	n := 0
+	l.eachRaw("praeposterius", func(lemma *Lemma, a Analysis) bool {
+		if lemma.Key == "praepostere" {
+			n++
+		}
+		return true
+	})
+	if n != 1 {
+		t.Errorf("praeposterius: %d raw analyses of praepostere, want 1", n)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	l, _ := New(dataDir)
+	adoria := l.Lemma("adoria")
+	out := adoria.ReferencesOut()
+	if len(out) != 1 || out[0].Key != "adorea" {
+		t.Errorf("adoria.ReferencesOut() = %v, want [adorea]", out)
+	}
+	var keys []string
+	for _, lemma := range l.ReferencesTo("aduersus2") {
+		keys = append(keys, lemma.Key)
+	}
+	if !slices.Equal(keys, []string{"aduersum", "aduorsum"}) {
+		t.Errorf("ReferencesTo(aduersus2) = %v, want [aduersum aduorsum]", keys)
+	}
+	if got := l.Lemma("Plato").ReferencesOut(); len(got) != 0 {
+		t.Errorf("Plato refers to unknown Platon, got %v", got)
+	}
+	if got := l.ReferencesTo("nonexistent"); got != nil {
+		t.Errorf("ReferencesTo(nonexistent) = %v", got)
+	}
+}
+
+func TestUnreachableMorphos(t *testing.T) {
+	l, _ := New(dataDir)
+	if w := l.Warnings(); len(w) != 0 {
+		t.Errorf("bundled data produced warnings: %v", w)
+	}
+
+	// Radical 2 has neither a rule nor any lemma giving it explicitly.
+	m := l.parseModel([]string{
+		"modele:broken",
+		"R:1:2,0",
+		"des:1-2:1:ă;ăe",
+		"des:3,4:2:ăm;ās",
+	})
+	if m == nil {
+		t.Fatal("parseModel returned nil")
+	}
+	got := m.UnreachableMorphos()
+	if len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("UnreachableMorphos() = %v, want [3 4]", got)
+	}
+
+	l.models[m.Name] = m
+	l.checkModels()
+	if w := l.Warnings(); len(w) != 1 || !strings.Contains(w[0].Message, "broken") {
+		t.Errorf("Warnings() = %v, want one warning about model broken", w)
+	}
+}
+
+func TestModelCycle(t *testing.T) {
+	l, _ := New(dataDir)
+	parse := func(lines ...string) *Model {
+		m := l.parseModel(lines)
+		l.models[m.Name] = m
+		return m
+	}
+	a := parse("modele:cycA", "pere:lupus")
+	b := parse("modele:cycB", "pere:cycA")
+	c := parse("modele:cycC", "pere:cycB")
+	parse("modele:orphan", "pere:nosuch")
+	a.parent = b // cycA → cycB → cycA, and cycC hangs from it
+	if a.EstUn("lupus") || c.EstUn("nosuch") || !c.EstUn("cycA") {
+		t.Error("EstUn on a cycle")
+	}
+	if pos := c.POS(); pos != POSNoun {
+		t.Errorf("POS() = %c, want n, inherited from lupus", pos)
+	}
+
+	l.checkModels()
+	var got []string
+	for _, w := range l.Warnings() {
+		got = append(got, w.Message)
+	}
+	want := []string{
+		"model cycA: circular parents cycA → cycB → cycA",
+		"model orphan: parent nosuch is not defined before it",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+	if a.Parent() != nil || b.Parent() != a {
+		t.Error("the cycle is not broken at cycA")
+	}
+}
+
+func TestGenerateAllFormsKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generating every form takes several seconds")
+	}
+	l, _ := New(dataDir)
+	vinum := l.Lemma("vinum")
+	found := false
+	for _, g := range l.GenerateAllForms() {
+		if g.Gr != Atone(g.Grq) || g.Key != NormalizeKey(g.Grq) {
+			t.Fatalf("%q: Gr = %q, Key = %q", g.Grq, g.Gr, g.Key)
+		}
+		if g.Lemma == vinum && g.MorphoIndex == 1 {
+			found = true
+			if g.Gr != "vinum" || g.Key != "uinum" {
+				t.Errorf("vinum nominative: Gr = %q, Key = %q; want vinum, uinum", g.Gr, g.Key)
+			}
+		}
+	}
+	if !found {
+		t.Error("GenerateAllForms lacks the nominative of vinum")
+	}
+}
+
+func TestLigatures(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, c := range []struct{ form, lemma string }{
+		{"aedes", "aedes"}, {"ædes", "aedes"},
+		{"poena", "poena"}, {"pœna", "poena"}, {"pœnæ", "poena"},
+		{"praesum", "praesum"}, {"præsum", "praesum"},
+		{"proelium", "proelium"}, {"prœlium", "proelium"},
+		// diphthongs of the desinence
+		{"hæc", "hic"}, {"quædam", "quidam"}, {"Musæ", "Musa"},
+	} {
+		if l.LemmatizeWord(c.form, false)[l.Lemma(c.lemma)] == nil {
+			t.Errorf("%s is not analysed as %s", c.form, c.lemma)
+		}
+	}
+	// poēta and aēr are read with a hiatus, not a diphthong.
+	for _, form := range []string{"pœta", "ær"} {
+		if mm := l.LemmatizeWord(form, false); len(mm) != 0 {
+			t.Errorf("%s has %d lemmas, want none", form, len(mm))
+		}
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	for _, c := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"p??lla", "puella", true},
+		{"p??lla", "puellam", false},
+		{"p*a", "puella", true},
+		{"*ll*", "puella", true},
+		{"*", "", true},
+		{"?", "", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+	} {
+		if got := globMatch([]rune(c.pattern), []rune(c.s)); got != c.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", c.pattern, c.s, got, c.want)
+		}
+	}
+}
+
+func TestFindFormsMatching(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generating every form takes several seconds")
+	}
+	l, _ := New(dataDir)
+	if _, err := l.FindFormsMatching("p??lla", 0); err == nil {
+		t.Error("FindFormsMatching without the form index: no error")
+	}
+	l.BuildFormIndex()
+	matches, err := l.FindFormsMatching("P??LLA", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, m := range matches {
+		if !globMatch([]rune("p??lla"), []rune(strings.ToLower(NormalizeKey(m.Form)))) {
+			t.Errorf("%q does not match p??lla", m.Form)
+		}
+		if m.Lemma.Key == "puella" && m.MorphoIndex == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("p??lla does not find the nominative of puella")
+	}
+
+	if matches, _ := l.FindFormsMatching("*", 5); len(matches) != 5 {
+		t.Errorf("*, limit 5: %d matches", len(matches))
+	}
+}
+
+func TestAmbiguityReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generating every form takes several seconds")
+	}
+	l, _ := New(dataDir)
+	report := l.AmbiguityReport()
+	var est *AmbiguousForm
+	for i, a := range report {
+		if i > 0 && report[i-1].Form >= a.Form {
+			t.Fatalf("report not in form order: %q before %q", report[i-1].Form, a.Form)
+		}
+		if len(a.Lemmas) < 2 {
+			t.Fatalf("%q has %d lemmas", a.Form, len(a.Lemmas))
+		}
+		if a.Form == "est" {
+			est = &report[i]
+		}
+	}
+	if est == nil {
+		t.Fatal("est is not reported")
+	}
+	var keys []string
+	for _, lemma := range est.Lemmas {
+		keys = append(keys, lemma.Key)
+	}
+	if !slices.Equal(keys, []string{"edo", "sum"}) {
+		t.Errorf("est lemmas = %v, want [edo sum]", keys)
+	}
+
+	var streamed []string
+	l.EachAmbiguousForm(func(a AmbiguousForm) bool {
+		streamed = append(streamed, a.Form)
+		return len(streamed) < 3
+	})
+	if len(streamed) != 3 || streamed[0] != report[0].Form || streamed[2] != report[2].Form {
+		t.Errorf("EachAmbiguousForm stopped at %v, want the first 3 forms of the report", streamed)
+	}
+}
+
+func TestInflectStem(t *testing.T) {
+	l, _ := New(dataDir)
+	table, err := l.InflectStem("blurgus", "lupus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := table.Cells[4]; len(got) != 1 || Atone(got[0]) != "blurgi" {
+		t.Errorf("genitive of blurgus = %v, want blurgi", got)
+	}
+	if table.Lemma.POS != POSNoun {
+		t.Errorf("blurgus POS = %c, want noun", table.Lemma.POS)
+	}
+	if len(l.LemmatizeWord("blurgi", false)) != 0 || l.Lemma("blurgus") != nil {
+		t.Error("InflectStem registered blurgus")
+	}
+	if _, err := l.InflectStem("blurgus", "nonexistent"); err == nil {
+		t.Error("no error for an unknown model")
+	}
+}
+
+func TestDeponents(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, key := range []string{"imitor", "loquor", "patior"} {
+		lemma := l.Lemma(key)
+		if lemma == nil {
+			t.Fatalf("Lemma(%q) is nil", key)
+		}
+		if !lemma.Deponent {
+			t.Errorf("%s is not flagged deponent", key)
+		}
+		for mn := range l.InflectionTable(lemma).Cells {
+			if desc := l.MorphoOf(lemma, mn); strings.Contains(desc, "passif") {
+				t.Errorf("%s cell %d labelled %q", key, mn, desc)
+			}
+		}
+	}
+	if l.Lemma("amo").Deponent {
+		t.Error("amo is flagged deponent")
+	}
+
+	// imitator is the future imperative, labelled passive in morphos.fr
+	for lemma, analyses := range l.LemmatizeWord("imitator", false) {
+		if lemma.Key != "imitor" {
+			continue
+		}
+		for _, a := range analyses {
+			if strings.Contains(a.MorphoDescription, "passif") {
+				t.Errorf("imitator analysed as %q", a.MorphoDescription)
+			}
+		}
+	}
+}
+
+func TestAllowedMorphos(t *testing.T) {
+	l, _ := New(dataDir)
+	l.SetLemmatizeOptions(LemmatizeOptions{AllowedMorphos: []int{1}}) // nominatif singulier
+	mm := l.LemmatizeWord("rosa", false)
+	rosa := mm[l.Lemma("rosa")]
+	if len(rosa) != 1 || rosa[0].MorphoIndex != 1 {
+		t.Errorf("rosa analyses = %v, want the nominative singular only", rosa)
+	}
+	for lemma, analyses := range mm {
+		for _, a := range analyses {
+			if a.MorphoIndex != 1 {
+				t.Errorf("%s: %s kept", lemma.Key, a.MorphoDescription)
+			}
+		}
+	}
+	if mm := l.LemmatizeWord("rosam", false); len(mm) != 0 {
+		t.Errorf("rosam has %d lemmas, want none", len(mm))
+	}
+	if l.IsValidForm("rosam") || !l.IsValidForm("rosa") {
+		t.Error("IsValidForm ignores AllowedMorphos")
+	}
+}
+
+func TestGreekSpellings(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, form := range []string{"filosophia", "filosofia", "timbra"} {
+		if mm := l.LemmatizeWord(form, false); len(mm) != 0 {
+			t.Errorf("%s lemmatized without GreekSpellings", form)
+		}
+	}
+	l.SetLemmatizeOptions(LemmatizeOptions{GreekSpellings: true})
+	for _, c := range []struct{ form, key string }{
+		{"philosophia", "philosophia"},
+		{"filosophia", "philosophia"},
+		{"Filosofiam", "philosophia"},
+		{"timbra", "thymbra"},
+		{"fantasma", "phantasma"},
+	} {
+		if _, ok := l.LemmatizeWord(c.form, true)[l.Lemma(c.key)]; !ok {
+			t.Errorf("%s: %s not found", c.form, c.key)
+		}
+	}
+	if !l.IsValidForm("filosophia") {
+		t.Error("IsValidForm(filosophia) = false")
+	}
+}
+
+func TestCertainFeatures(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, c := range []struct {
+		form, lemma string
+		feats       map[string]string
+	}{
+		{"bonam", "bonus", map[string]string{"Case": "Acc", "Gender": "Fem", "Number": "Sing"}},
+		{"puellae", "puella", map[string]string{"Gender": "Fem"}},
+		{"templi", "templum", map[string]string{"Case": "Gen", "Gender": "Neut", "Number": "Sing"}},
+		{"rosam", "", map[string]string{"Case": "Acc", "Gender": "Fem", "Number": "Sing"}},
+		// the passive forms of a deponent are active, as in MorphoOf
+		{"hortatur", "hortor", map[string]string{"Mood": "Ind", "Number": "Sing", "Person": "3", "Tense": "Pres", "VerbForm": "Fin", "Voice": "Act"}},
+		{"xyzzy", "", nil},
+	} {
+		mf := l.CertainFeatures(c.form)
+		key := ""
+		if mf.Lemma != nil {
+			key = mf.Lemma.Key
+		}
+		if key != c.lemma || !maps.Equal(mf.Feats, c.feats) {
+			t.Errorf("CertainFeatures(%s) = %s %v, want %s %v", c.form, key, mf.Feats, c.lemma, c.feats)
+		}
+	}
+}
+
+func TestVerbMood(t *testing.T) {
+	l, _ := New(dataDir)
+	has := func(ms []MoodMatch, want MoodMatch) bool {
+		for _, m := range ms {
+			if m.Lemma.Key == want.Lemma.Key && m.Mood == want.Mood &&
+				m.Person == want.Person && m.Number == want.Number {
+				return true
+			}
+		}
+		return false
+	}
+	uenio := l.Lemma("uenio")
+	for _, c := range []struct {
+		form string
+		want MoodMatch
+	}{
+		{"veni", MoodMatch{Lemma: uenio, Mood: "Imp", Person: 2, Number: "Sing"}},
+		{"venire", MoodMatch{Lemma: uenio, Mood: "Inf"}},
+		{"venite", MoodMatch{Lemma: uenio, Mood: "Imp", Person: 2, Number: "Plur"}},
+	} {
+		ms := l.VerbMood(c.form)
+		if !has(ms, c.want) {
+			t.Errorf("VerbMood(%s) = %v, want %s %d %s of uenio", c.form, ms, c.want.Mood, c.want.Person, c.want.Number)
+		}
+		for _, m := range ms {
+			if m.Lemma.POS != POSVerb || (m.Mood == "Inf") != (m.Person == 0) {
+				t.Errorf("VerbMood(%s): %+v", c.form, m)
+			}
+		}
+	}
+	if ms := l.VerbMood("venio"); len(ms) != 0 {
+		t.Errorf("VerbMood(venio) = %v, want none", ms)
+	}
+}
+
+func TestScanLine(t *testing.T) {
+	l, _ := New(dataDir)
+	kinds := func(feet []Foot) string {
+		var b strings.Builder
+		for _, f := range feet {
+			b.WriteString(strings.ToUpper(f.Kind.String()[:1]))
+		}
+		return b.String()
+	}
+	for _, c := range []struct{ line, want string }{
+		{"Arma virumque cano, Troiae qui primus ab oris", "DDSSDS"},
+		{"Tityre, tu patulae recubans sub tegmine fagi", "DDDSDS"},
+		{"litora, multum ille et terris iactatus et alto", "DSSSDS"},
+		{"Conticuere omnes intentique ora tenebant", "DSSSDS"},
+		{"Gallia est omnis divisa in partes tres", ""},
+	} {
+		if got := kinds(l.ScanLine(c.line)); got != c.want {
+			t.Errorf("ScanLine(%q) = %s, want %s", c.line, got, c.want)
+		}
+	}
+
+	feet := l.ScanLine("litora, multum ille et terris iactatus et alto")
+	if len(feet) != 6 || !slices.Equal(feet[1].Syllables, []Syllable{{Text: "mūl", Long: true}, {Text: "tŭm‿īl", Long: true}}) {
+		t.Errorf("second foot of Aeneid 1.3 = %v, want mūl tŭm‿īl", feet[1].Syllables)
+	}
+	for _, c := range []struct{ line, want string }{
+		{"Arma virumque cano, Troiae qui primus ab oris", "penthemimeral"},
+		{"quidve dolens regina deum tot volvere casus", "feminine"},
+	} {
+		if got := Caesura(l.ScanLine(c.line)); got != c.want {
+			t.Errorf("Caesura(%q) = %q, want %q", c.line, got, c.want)
+		}
+	}
+	// Without the elision of -um, "multum ille" reads mul-tu-mil.
+	if feet := l.ScanLineWithOptions("litora, multum ille et terris iactatus et alto", ScanOptions{}); feet != nil {
+		t.Errorf("Aeneid 1.3 fits without eliding -um: %s", kinds(feet))
+	}
+}
+
+func TestScan(t *testing.T) {
+	scan := func(form string) string {
+		var out []string
+		for _, s := range Scan(form) {
+			q := s.Vowel + ":" + s.Quantity.String()
+			if s.ByPosition {
+				q += "+position"
+			}
+			out = append(out, q)
+		}
+		return strings.Join(out, " ")
+	}
+	for form, want := range map[string]string{
+		"rŏsăm":   "ŏ:short ă:short",
+		"ămō̆":    "ă:short ō̆:common",
+		"pŭĕllāe": "ŭ:short ĕ:short+position āe:long",
+		"pōenă":   "ōe:long ă:short",
+		"āudĭō̆":  "āu:long ĭ:short ō̆:common",
+		"pătrĭs":  "ă:common ĭ:short",
+		"pātrĭs":  "ā:long ĭ:short",
+		"ĕxĭt":    "ĕ:short+position ĭ:short",
+		"quŏquĕ":  "ŏ:short ĕ:short",
+	} {
+		if got := scan(form); got != want {
+			t.Errorf("Scan(%q) = %s, want %s", form, got, want)
+		}
+	}
+	if s := Scan("pŭĕllāe"); s[1].Text != "ĕl" || !s[1].Long || s[0].Long || !s[2].WordEnd {
+		t.Errorf("Scan(pŭĕllāe) = %+v", s)
+	}
+	if s := Scan("st"); s == nil || len(s) != 0 {
+		t.Errorf("Scan(st) = %#v, want an empty slice", s)
+	}
+	// The silent u of the lexicon is neither a vowel nor a consonant.
+	for _, c := range parseScanWord("sụāvĭs", true, false) {
+		if c.base == 'ụ' && (c.vowel || c.weight != 0) {
+			t.Errorf("sụāvĭs: ụ read as %+v", c)
+		}
+	}
+}
+
+func TestMergedInflectionTable(t *testing.T) {
+	l, _ := New(dataDir)
+	// sĕro, serui and sĕro2, sevi share the model lego.
+	m := l.MergedInflectionTable("sero2")
+	if m == nil || len(m.Lemmas) != 2 || m.Lemmas[0].Key != "sero" || m.Lemmas[1].Key != "sero2" {
+		t.Fatalf("MergedInflectionTable(sero2) = %+v, want sero and sero2", m)
+	}
+	if c := m.Cells[121]; c.ByLemma != nil || !slices.Equal(c.Forms, []string{"sĕrō̆"}) {
+		t.Errorf("cell 121 = %+v, want sĕrō̆ for both", c)
+	}
+	c := m.Cells[139]
+	if !slices.Equal(c.Forms, []string{"sĕrŭī", "sēvī"}) ||
+		!slices.Equal(c.ByLemma[m.Lemmas[0]], []string{"sĕrŭī"}) ||
+		!slices.Equal(c.ByLemma[m.Lemmas[1]], []string{"sēvī"}) {
+		t.Errorf("cell 139 = %+v, want sĕrŭī and sēvī apart", c)
+	}
+	if m := l.MergedInflectionTable("nonexistentword"); m != nil {
+		t.Errorf("MergedInflectionTable(nonexistentword) = %+v, want nil", m)
+	}
+}
+
+func TestVerboseDesinence(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, a := range l.LemmatizeWord("lupum", false)[l.Lemma("lupus")] {
+		if a.Desinence != nil {
+			t.Errorf("lupum: desinence %+v set without Verbose", a.Desinence)
+		}
+	}
+
+	l.SetLemmatizeOptions(LemmatizeOptions{Verbose: true})
+	analyses := l.LemmatizeWord("lupum", false)[l.Lemma("lupus")]
+	if len(analyses) == 0 {
+		t.Fatal("lupum: no analysis of lupus")
+	}
+	for _, a := range analyses {
+		d := a.Desinence
+		if d == nil || d.Model.Name != "lupus" || d.MorphoNum != a.MorphoIndex || d.RadNum != 1 || d.Gr != "um" {
+			t.Errorf("lupum: desinence %+v, want um of lupus on radical 1", d)
+		}
+	}
+	for _, a := range l.LemmatizeWord("bobus", false)[l.Lemma("bos")] {
+		if a.Attested && a.Desinence != nil {
+			t.Errorf("bobus: attested analysis with desinence %+v", a.Desinence)
+		}
+	}
+}
+
+func TestScores(t *testing.T) {
+	l, _ := New(dataDir)
+	l.SetLemmatizeOptions(LemmatizeOptions{GreekSpellings: true})
+	for _, tc := range []struct {
+		form, key string
+		want      Source
+	}{
+		{"puellae", "puella", 0},
+		{"amasti", "amo", SourceContraction},
+		{"affero", "adfero", SourceAssimilation},
+		{"rosaque", "rosa", SourceEnclitic},
+		{"filosofia", "philosophia", SourceGreekSpelling},
+	} {
+		analyses := l.LemmatizeWord(tc.form, false)[l.Lemma(tc.key)]
+		if len(analyses) == 0 {
+			t.Errorf("%s: no analysis as %s", tc.form, tc.key)
+			continue
+		}
+		if got := analyses[0].Derivation; got != tc.want {
+			t.Errorf("%s: Derivation = %b, want %b", tc.form, got, tc.want)
+		}
+	}
+
+	mm := l.LemmatizeWord("rosaque", false)
+	scores := l.Scores(mm)
+	rosa, rodo := scores[l.Lemma("rosa")], scores[l.Lemma("rodo")]
+	if len(rosa) != len(mm[l.Lemma("rosa")]) || len(rodo) == 0 {
+		t.Fatalf("Scores = %v", scores)
+	}
+	want := AnalysisScore{FrequencyRank: 0, Derivation: SourceEnclitic, StemLength: 3}
+	if rosa[0] != want {
+		t.Errorf("score of rosa = %+v, want %+v", rosa[0], want)
+	}
+	if rodo[0].FrequencyRank != 1 || rodo[0].Score() >= rosa[0].Score() {
+		t.Errorf("score of rodo = %+v (%d), want below rosa (%d)", rodo[0], rodo[0].Score(), rosa[0].Score())
+	}
+}
+
+func TestMaxAnalyses(t *testing.T) {
+	l, _ := New(dataDir)
+	// natis is read as 8 lemmas in 40 analyses.
+	all := l.LemmatizeWord("natis", false)
+	if len(all) < 3 {
+		t.Fatalf("natis: %d lemmas, want several", len(all))
+	}
+	ranked := l.RankLemmas(all)
+
+	l.SetLemmatizeOptions(LemmatizeOptions{MaxLemmas: 2, MaxAnalysesPerLemma: 1})
+	mm := l.LemmatizeWord("natis", false)
+	if len(mm) != 2 {
+		t.Fatalf("natis: %d lemmas with MaxLemmas 2", len(mm))
+	}
+	best := func(analyses []Analysis) int {
+		score := analysisScore(analyses[0]).Score()
+		for _, a := range analyses[1:] {
+			score = max(score, analysisScore(a).Score())
+		}
+		return score
+	}
+	for _, lemma := range ranked[:2] {
+		if got := mm[lemma]; len(got) != 1 || analysisScore(got[0]).Score() != best(all[lemma]) {
+			t.Errorf("natis, %s: %v, want its best-scoring analysis only", lemma.Key, got)
+		}
+	}
+
+	// The first analysis of the noun natis, of a shorter stem, is not its
+	// best.
+	natis := l.Lemma("natis")
+	if analysisScore(all[natis][0]).Score() == best(all[natis]) {
+		t.Fatal("natis: the first analysis of the noun is its best")
+	}
+	l.SetLemmatizeOptions(LemmatizeOptions{MaxAnalysesPerLemma: 1})
+	if got := l.LemmatizeWord("natis", false)[natis]; len(got) != 1 || analysisScore(got[0]).Score() != best(all[natis]) {
+		t.Errorf("natis, natis: %v, want its best-scoring analysis only", got)
+	}
+}
+
+func TestLocative(t *testing.T) {
+	l, _ := New(dataDir)
+	l.SetLabelLocale("en")
+	labels := func(form, key string) []string {
+		var out []string
+		for _, a := range l.LemmatizeWord(form, false)[l.Lemma(key)] {
+			out = append(out, l.Label(a.MorphoIndex))
+		}
+		return out
+	}
+	// Romae is both the locative and the genitive of Roma.
+	romae := labels("Romae", "Roma")
+	if !slices.Contains(romae, "locative") || !slices.Contains(romae, "genitive singular") {
+		t.Errorf("Romae: %v, want locative and genitive singular", romae)
+	}
+	if rosae := labels("rosae", "rosa"); slices.Contains(rosae, "locative") {
+		t.Errorf("rosae: %v, want no locative", rosae)
+	}
+	// The model of humus has no locative: humi is added.
+	if humi := labels("humi", "humus"); !slices.Contains(humi, "locative") {
+		t.Errorf("humi: %v, want locative", humi)
+	}
+	loc := slices.Index(l.morphos, "locatif")
+	if got := l.InflectionTable(l.Lemma("humus")).Cells[loc]; !slices.Equal(got, []string{"hŭmī"}) {
+		t.Errorf("locative of humus = %v, want hŭmī", got)
+	}
+	if got := l.UDFeatures(loc); got != "Case=Loc" {
+		t.Errorf("UDFeatures(%d) = %q, want Case=Loc", loc, got)
+	}
+}
+
+func TestMinFrequency(t *testing.T) {
+	l, _ := New(dataDir)
+	l.SetLemmatizeOptions(LemmatizeOptions{MinFrequency: 50})
+
+	result := l.LemmatizeWord("populi", false)
+	if len(result) != 1 {
+		t.Errorf("populi: %d lemmas with MinFrequency 50, want 1", len(result))
+	}
+	for lemma := range result {
+		if lemma.Key != "populus" {
+			t.Errorf("populi: kept %s, want populus", lemma.Key)
+		}
+	}
+
+	// abacus is rare but the only reading: it is kept.
+	result = l.LemmatizeWord("abacus", false)
+	if len(result) != 1 {
+		t.Errorf("abacus: %d lemmas with MinFrequency 50, want 1", len(result))
+	}
+}
+
+func TestDataFingerprint(t *testing.T) {
+	a, _ := New(dataDir)
+	b, _ := New(dataDir)
+	if a.DataFingerprint() == "" {
+		t.Fatal("DataFingerprint() is empty")
+	}
+	if a.DataFingerprint() != b.DataFingerprint() {
+		t.Errorf("fingerprints differ for identical data: %s, %s", a.DataFingerprint(), b.DataFingerprint())
+	}
+	b.Lemma("lupus").AddTranslation("fr", "loup-garou")
+	if a.DataFingerprint() == b.computeFingerprint() {
+		t.Error("fingerprint unchanged after editing a translation")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	l, _ := New(dataDir)
+	if r := l.Lemma("noctus").Register(); r != RegisterArchaic {
+		t.Errorf("noctus.Register() = %v, want archaic", r)
+	}
+	if r := l.Lemma("nox").Register(); r != RegisterClassical {
+		t.Errorf("nox.Register() = %v, want classical", r)
+	}
+
+	l.SetLemmatizeOptions(LemmatizeOptions{ExcludeRegisters: []Register{RegisterArchaic}})
+	for lemma := range l.LemmatizeWord("noctu", false) {
+		if lemma.Register() == RegisterArchaic {
+			t.Errorf("noctu: archaic %s kept", lemma.Key)
+		}
+	}
+	// siet is only archaic: it is kept.
+	if len(l.LemmatizeWord("siet", false)) == 0 {
+		t.Error("siet: only reading dropped")
+	}
+
+	// noctu is first the classical noctu, more frequent, unless the
+	// archaic register is preferred.
+	l.SetLemmatizeOptions(LemmatizeOptions{PreferRegisters: []Register{RegisterArchaic}})
+	mm := l.LemmatizeWord("noctu", false)
+	if got := l.RankLemmas(mm); len(got) != 2 || got[0].Key != "noctus" {
+		t.Errorf("noctu, RankLemmas: %v, want noctus first", got)
+	}
+	if got := l.LemmatizeWordRanked("noctu", false); len(got) != 2 || got[0].Lemma.Key != "noctus" {
+		t.Errorf("noctu, LemmatizeWordRanked: noctus not first")
+	}
+	ss := l.Scores(mm)
+	if ss[l.Lemma("noctus")][0].Score() <= ss[l.Lemma("noctu")][0].Score() {
+		t.Error("noctu: noctus does not score above noctu")
+	}
+	l.SetLemmatizeOptions(LemmatizeOptions{})
+	if got := l.RankLemmas(mm); got[0].Key != "noctu" {
+		t.Errorf("noctu, RankLemmas: %v, want noctu first", got)
+	}
+}
+
+func TestPOSSource(t *testing.T) {
+	l, _ := New(dataDir)
+	for key, want := range map[string]POSSource{
+		"rosa": POSFromModel, "et": POSFromHeuristic, "unus": POSFromHeuristic,
+	} {
+		if got := l.Lemma(key).POSSource(); got != want {
+			t.Errorf("%s.POSSource() = %v, want %v", key, got, want)
+		}
+	}
+
+	// The seventh field of a line overrides the guess from "conj.".
+	lemma := newLemma("quodsi=quōdsī|inv|||conj. et pron.|1|p")
+	if lemma.POS != POSPronoun || lemma.POSSource() != POSFromData {
+		t.Errorf("quodsi: POS %c from %v, want p from data", lemma.POS, lemma.POSSource())
+	}
+
+	// An unknown code is reported and ignored.
+	text := strings.Replace(readDataFile(t, "lemmes.la"), "quōdsī|inv|||conj. et pron.|1\n", "quōdsī|inv|||conj. et pron.|1|p\n", 1)
+	text = strings.Replace(text, "quŏd|inv|||conj. sub.|3725\n", "quŏd|inv|||conj. sub.|3725|x\n", 1)
+	l, err := New(copyDataDir(t, map[string]string{"lemmes.la": text}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Lemma("quodsi").POS; got != POSPronoun {
+		t.Errorf("quodsi from the data: POS %c, want p", got)
+	}
+	if got := l.Lemma("quod").POSSource(); got != POSFromHeuristic {
+		t.Errorf("quod: POS from %v, want heuristic", got)
+	}
+	if w := l.Warnings(); len(w) != 1 || !strings.Contains(w[0].Message, `unknown part-of-speech code "x"`) {
+		t.Errorf("Warnings() = %v, want one about code x", w)
+	}
+}
+
+func TestLexiconCitation(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, c := range []struct {
+		key, abbreviated, full string
+	}{
+		{"amo", "amō, āvī, ātum, āre", "amō, amāre, amāvī, amātum"},
+		{"moneo", "moneō, uī, itum, ēre", "moneō, monēre, monuī, monitum"},
+		{"rego", "regō, rēxī, rēctum, ere", "regō, regere, rēxī, rēctum"},
+		{"imitor", "imitor, ātus sum, ārī", "imitor, imitārī, imitātus sum"},
+		{"rosa", "rosa, ae, f.", "rosa, rosae, f."},
+		{"homo", "homō, inis, m.", "homō, hominis, m."},
+		{"castra", "cāstra, ōrum, n.", "cāstra, cāstrōrum, n."},
+		{"bonus", "bonus, a, um", "bonus, bona, bonum"},
+		{"celer", "celer, eris, ere", "celer, celeris, celere"},
+		{"ingens", "īngēns, ēntis", "īngēns, īngēntis"},
+		{"et", "et", "et"},
+	} {
+		lemma := l.Lemma(c.key)
+		l.SetCitationStyle(CitationAbbreviated)
+		if got := l.LexiconCitation(lemma); got != c.abbreviated {
+			t.Errorf("abbreviated citation of %s = %q, want %q", c.key, got, c.abbreviated)
+		}
+		l.SetCitationStyle(CitationFull)
+		if got := l.LexiconCitation(lemma); got != c.full {
+			t.Errorf("full citation of %s = %q, want %q", c.key, got, c.full)
+		}
+	}
+}
+
+// TestReload is meant for the race detector: go test -race -run Reload.
+func TestReload(t *testing.T) {
+	l, _ := New(dataDir)
+	l.SetCitationStyle(CitationFull)
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if len(l.LemmatizeWord("rosam", false)) == 0 {
+					t.Error("rosam: no analysis during Reload")
+					return
+				}
+				l.InflectionTable(l.Lemma("amo"))
+			}
+		}()
+	}
+	for range 2 {
+		if err := l.Reload(dataDir); err != nil {
+			t.Error(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if err := l.Reload(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Reload(missing dir): no error")
+	}
+	if len(l.LemmatizeWord("rosam", false)) == 0 || l.citationStyle != CitationFull {
+		t.Error("a failed Reload lost the data or the settings")
+	}
+}
+
+func TestValidateData(t *testing.T) {
+	// The bundled data only has lemmas of the missing model bene.
+	warnings, err := ValidateData(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range warnings {
+		if !strings.Contains(w.Message, `unknown model "bene"`) {
+			t.Errorf("bundled data: unexpected warning %v", w)
+		}
+	}
+
+	if _, err := ValidateData(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("ValidateData(missing dir): no error")
+	}
+
+	// A copy of the data, broken on purpose.
+	dir := copyDataDir(t, map[string]string{
+		"lemmes.la":  readDataFile(t, "lemmes.la") + "gŏbbŭs\ngŏbbŭs|catus|||i, m.|1\n",
+		"irregs.la":  readDataFile(t, "irregs.la") + "zўgōnĕm:zygon:7\n",
+		"modeles.la": readDataFile(t, "modeles.la") + "\nmodele:cattus\nR:1:2,0\ndes:1-12,999:1:$lupus\nabs:0\npos:n\n",
+	})
+	warnings, err = ValidateData(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"malformed lemma line",
+		`lemma gobbus: unknown model "catus"`,
+		"malformed irregular form or unknown lemma",
+		"model cattus: morphos [0 999] out of range",
+	} {
+		if !slices.ContainsFunc(warnings, func(w LoadWarning) bool { return strings.Contains(w.Message, want) }) {
+			t.Errorf("broken data: no warning containing %q in %v", want, warnings)
+		}
+	}
+
+	// New still loads the broken data, silently skipping its lines.
+	l, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range l.Warnings() {
+		if strings.Contains(w.Message, "malformed") || strings.Contains(w.Message, "unknown model") {
+			t.Errorf("New: strict warning %v", w)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	l, _ := New(dataDir)
+	has := func(c Completion, key string) bool {
+		for _, lemma := range c.Lemmas {
+			if lemma.Key == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	// "pue" is a prefix of the radical, "puellar" runs into an ending.
+	for _, prefix := range []string{"pue", "puell", "puellar"} {
+		if c := l.Complete(prefix, 0); !has(c, "puella") {
+			t.Errorf("Complete(%q): puella missing", prefix)
+		}
+	}
+	if c := l.Complete("puellax", 0); has(c, "puella") {
+		t.Error(`Complete("puellax"): puella should not be a candidate`)
+	}
+	if c := l.Complete("pu", 5); len(c.Lemmas) != 5 {
+		t.Errorf(`Complete("pu", 5): got %d lemmas`, len(c.Lemmas))
+	}
+
+	c := l.Complete("puellae", 0)
+	if len(c.Analyses) == 0 {
+		t.Error(`Complete("puellae"): no analyses of the full form`)
+	}
+	if c := l.Complete("puel", 0); len(c.Analyses) != 0 {
+		t.Error(`Complete("puel"): unexpected analyses`)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	l, _ := New(dataDir)
+	text := "Arma virumque cano, Troiae qui primus ab oris"
+	want := l.LemmatizeText(text)
+	var got []LemmatizationResult
+	for res := range l.Tokens(text) {
+		got = append(got, res)
+		if res.Token == "cano" {
+			break
+		}
+	}
+	if len(got) != 3 || got[2].Start != want[2].Start || len(got[1].Analyses) != len(want[1].Analyses) {
+		t.Errorf("Tokens up to cano = %v, want the first 3 of %v", got, want)
+	}
+
+	var keys []string
+	for lemma := range l.Lemmas() {
+		keys = append(keys, lemma.Key)
+		if len(keys) == 100 {
+			break
+		}
+	}
+	if len(keys) != 100 || !slices.IsSorted(keys) {
+		t.Errorf("first lemmas = %v, want 100 sorted keys", keys)
+	}
+	if n := len(slices.Collect(l.Lemmas())); n != len(l.lemmas) {
+		t.Errorf("Lemmas yields %d lemmas, want %d", n, len(l.lemmas))
+	}
+}
+
+func TestLemmatizeTextDecomposed(t *testing.T) {
+	l, _ := New(dataDir)
+	// "fāma" with the macron as a separate combining codepoint.
+	results := l.LemmatizeText("fa\u0304ma uolat")
+	var tokens []string
+	for _, r := range results {
+		tokens = append(tokens, r.Token)
+	}
+	if len(tokens) != 2 || tokens[0] != "f\u0101ma" {
+		t.Errorf("tokens = %q, want [\"fāma\" \"uolat\"]", tokens)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	text := "Gallia est omnis diuisa in partes tres, quarum unam incolunt Belgae... «Arma» 12 uirumque"
+	var got []string
+	for _, tok := range Tokenize(text) {
+		if tok.Text != text[tok.Start:tok.End] {
+			t.Errorf("token %q at %d:%d reads %q", tok.Text, tok.Start, tok.End, text[tok.Start:tok.End])
+		}
+		s := tok.Text
+		if !tok.IsWord {
+			s = "[" + s + "]"
+		}
+		if tok.SentenceStart {
+			s = "^" + s
+		}
+		got = append(got, s)
+	}
+	want := "^Gallia est omnis diuisa in partes tres [,] quarum unam incolunt Belgae [...] [«] ^Arma [»] [12] uirumque"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Tokenize = %s\nwant       %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestJoinHyphenated(t *testing.T) {
+	text := "puellae ro-\nsam pu-\r\n  ellae dant. Semi-deus"
+	var got []string
+	for _, tok := range TokenizeWithOptions(text, TokenizeOptions{JoinHyphenated: true}) {
+		got = append(got, tok.Text)
+		if tok.Text == "rosam" && text[tok.Start:tok.End] != "ro-\nsam" {
+			t.Errorf("rosam spans %q", text[tok.Start:tok.End])
+		}
+	}
+	want := "puellae rosam puellae dant . Semi - deus"
+	if strings.Join(got, " ") != want {
+		t.Errorf("tokens = %s, want %s", strings.Join(got, " "), want)
+	}
+	if n := len(Tokenize(text)); n != 12 {
+		t.Errorf("Tokenize without joining: %d tokens, want 12", n)
+	}
+
+	l, _ := New(dataDir)
+	l.SetLemmatizeOptions(LemmatizeOptions{JoinHyphenated: true})
+	results := l.LemmatizeText(text)
+	if results[1].Token != "rosam" || results[1].Analyses[l.Lemma("rosa")] == nil {
+		t.Errorf("second word = %q, not analysed as rosa", results[1].Token)
+	}
+}
+
+func TestInscriptions(t *testing.T) {
+	l, _ := New(dataDir)
+	text := "IMPERATOR CAESAR DIVI F AVGVSTVS PONTIFEX MAXIMVS ROMAE DEDIT"
+	keys := func() map[string][]string {
+		out := map[string][]string{}
+		for _, r := range l.LemmatizeText(text) {
+			for _, lemma := range l.RankLemmas(r.Analyses) {
+				out[r.Token] = append(out[r.Token], lemma.Key)
+			}
+		}
+		return out
+	}
+	if got := keys(); got["DIVI"] != nil || got["PONTIFEX"] != nil {
+		t.Errorf("without Inscriptions: DIVI %v, PONTIFEX %v, want none", got["DIVI"], got["PONTIFEX"])
+	}
+
+	l.SetLemmatizeOptions(LemmatizeOptions{Inscriptions: true})
+	got := keys()
+	for token, key := range map[string]string{
+		"IMPERATOR": "imperator", "CAESAR": "Caesar", "DIVI": "diuus",
+		"AVGVSTVS": "augustus", "PONTIFEX": "pontifex", "MAXIMVS": "Maximus",
+		"ROMAE": "Roma", "DEDIT": "do",
+	} {
+		if !slices.Contains(got[token], key) {
+			t.Errorf("%s: %v, want %s", token, got[token], key)
+		}
+	}
+	if !slices.Contains(got["AVGVSTVS"], "Augustus2") {
+		t.Errorf("AVGVSTVS: %v, want the proper noun Augustus too", got["AVGVSTVS"])
+	}
+	// Capitals do not make a word start a sentence.
+	for _, r := range l.LemmatizeText(text) {
+		if r.SentenceStart != (r.Start == 0) {
+			t.Errorf("%s: SentenceStart %v", r.Token, r.SentenceStart)
+		}
+	}
+
+	// A text in lower case is read as usual.
+	for _, r := range l.LemmatizeText("et Roma") {
+		if r.Token == "Roma" && len(r.Analyses) != 1 {
+			t.Errorf("Roma in lower-case text: %d lemmas, want 1", len(r.Analyses))
+		}
+	}
+}
+
+func TestSegmentContinuous(t *testing.T) {
+	l, _ := New(dataDir)
+	text := "SENATVS·POPVLVSQVEROMANVS CAESAR DIVIF"
+	var got []string
+	for _, r := range l.SegmentContinuous(text) {
+		if text[r.Start:r.End] != r.Token {
+			t.Errorf("%s spans %q", r.Token, text[r.Start:r.End])
+		}
+		var keys []string
+		for _, lemma := range l.RankLemmas(r.Analyses) {
+			keys = append(keys, lemma.Key)
+		}
+		got = append(got, r.Token+"="+strings.Join(keys, ","))
+	}
+	want := []string{
+		"SENATVS=senatus,Senatus2", "POPVLVSQVE=populus,populus2", "ROMANVS=Romanus,Romanus2",
+		"CAESAR=Caesar", "DIVI=diuus,diuus2,diuum", "F=",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("SegmentContinuous = %v, want %v", got, want)
+	}
+
+	// A text with spaces keeps its words.
+	if n := len(l.SegmentContinuous("arma virumque cano")); n != 3 {
+		t.Errorf("arma virumque cano: %d words, want 3", n)
+	}
+}
+
+func TestCRLFData(t *testing.T) {
+	crlfFiles := map[string]string{}
+	for _, name := range dataFileNames(t) {
+		crlfFiles[name] = strings.ReplaceAll(readDataFile(t, name), "\n", "\r\n")
+	}
+
+	l, _ := New(dataDir)
+	crlf, err := New(copyDataDir(t, crlfFiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crlf.DataFingerprint() != l.DataFingerprint() {
+		t.Error("CRLF data parsed differently from the original")
+	}
+	if got, want := crlf.Morpho(1), l.Morpho(1); got != want {
+		t.Errorf("Morpho(1) = %q, want %q", got, want)
+	}
+}
+
+func TestShuffledMorphos(t *testing.T) {
+	// Reverse the numbered descriptions and add one past the end.
+	var head, numbered []string
+	lines := strings.Split(readDataFile(t, "morphos.fr"), "\n")
+	i := 0
+	for ; i < len(lines) && !strings.HasPrefix(lines[i], "! --- "); i++ {
+		if strings.HasPrefix(lines[i], "!") || !strings.Contains(lines[i], ":") {
+			head = append(head, lines[i])
+		} else {
+			numbered = append(numbered, lines[i])
+		}
+	}
+	slices.Reverse(numbered)
+	numbered = append(numbered, "500:ablatif pluriel")
+	dir := copyDataDir(t, map[string]string{
+		"morphos.fr": strings.Join(slices.Concat(head, numbered, lines[i:]), "\n"),
+	})
+
+	l, _ := New(dataDir)
+	shuffled, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m := 1; l.Morpho(m) != ""; m++ {
+		if got, want := shuffled.Morpho(m), l.Morpho(m); got != want {
+			t.Fatalf("Morpho(%d) = %q, want %q", m, got, want)
+		}
+		if got, want := shuffled.UDFeatures(m), l.UDFeatures(m); got != want {
+			t.Errorf("UDFeatures(%d) = %q, want %q", m, got, want)
+		}
+	}
+	if got := shuffled.UDFeatures(500); got != "Case=Abl|Number=Plur" {
+		t.Errorf("UDFeatures(500) = %q, want Case=Abl|Number=Plur", got)
+	}
+	if got := shuffled.Morpho(499); got != "" {
+		t.Errorf("Morpho(499) = %q, want none", got)
+	}
+	for lemma, analyses := range shuffled.LemmatizeWord("lupi", false) {
+		if lemma.Key != "lupus" {
+			continue
+		}
+		for _, a := range analyses {
+			if !strings.Contains(a.MorphoDescription, "génitif singulier") &&
+				!strings.Contains(a.MorphoDescription, "nominatif pluriel") &&
+				!strings.Contains(a.MorphoDescription, "vocatif pluriel") {
+				t.Errorf("lupi analysed as %q", a.MorphoDescription)
+			}
+		}
+	}
+}
+
+func TestBOMData(t *testing.T) {
+	bomFiles := map[string]string{}
+	for _, name := range dataFileNames(t) {
+		text := readDataFile(t, name)
+		switch name {
+		case "morphos.fr":
+			// Start with the first morpho and the first lemma, as
+			// hand-written files would, rather than with a comment.
+			text = "1:nominatif singulier\n" + text
+		case "lemmes.la":
+			text = "zzzum=zzzum|templum|||i, n.|1\n" + text
+		}
+		bomFiles[name] = "\ufeff" + text
+	}
+
+	bom, err := New(copyDataDir(t, bomFiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bom.Morpho(1); got != "nominatif singulier" {
+		t.Errorf("Morpho(1) = %q, want nominatif singulier", got)
+	}
+	if bom.Lemma("zzzum") == nil {
+		t.Error("the first lemma of a BOM-prefixed lemmes.la is lost")
+	}
+	if bom.Lemma("rosa").Translation("en") == "" {
+		t.Error("BOM-prefixed translations are lost")
+	}
+}
+
+func TestGzipData(t *testing.T) {
+	l, _ := New(dataDir)
+	gzipped := func(text string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(text))
+		zw.Close()
+		return buf.String()
+	}
+	dir := copyDataDir(t, map[string]string{
+		"morphos.fr.gz": gzipped(readDataFile(t, "morphos.fr")),
+		// The plain file wins over a gzipped one.
+		"lemmes.en.gz": gzipped("English\nrosa:wrong\n"),
+	})
+	if err := os.Remove(filepath.Join(dir, "morphos.fr")); err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m := 1; m < 420; m++ {
+		if gz.Morpho(m) != l.Morpho(m) {
+			t.Fatalf("Morpho(%d) = %q from morphos.fr.gz, want %q", m, gz.Morpho(m), l.Morpho(m))
+		}
+	}
+	if got, want := gz.Lemma("rosa").Translation("en"), l.Lemma("rosa").Translation("en"); got != want {
+		t.Errorf("rosa in English = %q, want %q from the plain lemmes.en", got, want)
+	}
+	if _, ok := gz.Languages()["gz"]; ok {
+		t.Error("lemmes.en.gz read as the language gz")
+	}
+}
+
+func TestScanLines(t *testing.T) {
+	sc := newScanner(strings.NewReader("a\r\nb\rc\nd\r"))
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if want := []string{"a", "b", "c", "d"}; !slices.Equal(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestKeepSpelling(t *testing.T) {
+	l, _ := New(dataDir)
+	marks := func(form, key string) []string {
+		var out []string
+		for lemma, analyses := range l.LemmatizeWord(form, false) {
+			if lemma.Key != key {
+				continue
+			}
+			for _, a := range analyses {
+				out = append(out, a.FormWithMarks)
+			}
+		}
+		return out
+	}
+
+	// The lexicon spells consonantal u and i as v and j.
+	if got := marks("uinum", "uinum"); !slices.Contains(got, "vīnŭm") {
+		t.Errorf("uinum: got %q, want vīnŭm by default", got)
+	}
+
+	l.SetLemmatizeOptions(LemmatizeOptions{KeepSpelling: true})
+	cases := []struct{ form, key, want string }{
+		{"vinum", "uinum", "vīnŭm"},
+		{"uinum", "uinum", "uīnŭm"},
+		{"Vergilius", "Uergilius", "Vērgĭlĭŭs"},
+		{"Iulius", "Iulius", "Iūlĭŭs"},
+		{"iuvenis", "iuuenis", "iŭvĕnĭs"},
+		{"virumque", "uir", "vĭrŭm"},
+	}
+	for _, c := range cases {
+		if got := marks(c.form, c.key); !slices.Contains(got, c.want) {
+			t.Errorf("%s: got %q, want %s", c.form, got, c.want)
+		}
+	}
+}
+
+func TestModelParadigm(t *testing.T) {
+	l, _ := New(dataDir)
+	slots := l.ModelParadigm("lupus")
+	var forms []string
+	for _, s := range slots {
+		forms = append(forms, s.Forms[0])
+	}
+	want := []string{"-ŭs", "-ĕ", "-ŭm", "-ī", "-ō", "-ō", "-ī", "-ī", "-ōs", "-ōrŭm", "-īs", "-īs"}
+	if !slices.Equal(forms, want) {
+		t.Errorf("lupus paradigm = %q, want %q", forms, want)
+	}
+	if len(slots) > 0 && slots[0].Label != l.Morpho(1) {
+		t.Errorf("slot 1 label = %q, want %q", slots[0].Label, l.Morpho(1))
+	}
+
+	for _, s := range l.ModelParadigm("amo") {
+		// 139: 1ère singulier indicatif parfait actif
+		if s.MorphoIndex == 139 && s.Forms[0] != "1-ī" {
+			t.Errorf("amo perfect = %q, want 1-ī", s.Forms)
+		}
+	}
+	if l.ModelParadigm("nonexistent") != nil {
+		t.Error("unknown model: want nil")
+	}
+}
+
+func TestParseMorpho(t *testing.T) {
+	l, _ := New(dataDir)
+	for m, want := range map[int]Morphology{
+		// nominatif masculin singulier participe parfait passif
+		303: {Case: CaseNominative, Number: NumberSingular, Gender: GenderMasculine,
+			Tense: TensePerfect, Mood: MoodParticiple, Voice: VoicePassive},
+		// 1ère singulier indicatif présent actif
+		121: {Number: NumberSingular, Tense: TensePresent, Mood: MoodIndicative,
+			Voice: VoiceActive, Person: 1},
+		// supin en -um
+		265: {Mood: MoodSupine},
+		0:   {},
+		999: {},
+	} {
+		if got := l.ParseMorpho(m); got != want {
+			t.Errorf("ParseMorpho(%d) = %+v, want %+v", m, got, want)
+		}
+	}
+
+	// Analyses carry the features alongside the description, active for
+	// a deponent.
+	as := l.LemmatizeWord("imitantor", false)[l.Lemma("imitor")]
+	if len(as) == 0 {
+		t.Fatal("imitantor: no analysis of imitor")
+	}
+	for _, a := range as {
+		if a.Morphology.Voice != VoiceActive || l.ParseMorpho(a.MorphoIndex).Voice != VoicePassive {
+			t.Errorf("imitantor %s: Voice %v, want active", a.MorphoDescription, a.Morphology.Voice)
+		}
+	}
+	found := false
+	for _, a := range l.LemmatizeWord("rosam", false)[l.Lemma("rosa")] {
+		found = found || a.Morphology == Morphology{Case: CaseAccusative, Number: NumberSingular}
+	}
+	if !found {
+		t.Error("rosam: no accusative singular Morphology")
+	}
+}
+
+func TestLabelLocale(t *testing.T) {
+	l, _ := New(dataDir)
+	tests := []struct {
+		lang string
+		m    int
+		want string
+	}{
+		{"", 4, "génitif singulier"},
+		{"fr", 4, "génitif singulier"},
+		{"xx", 4, "génitif singulier"},
+		{"en", 4, "genitive singular"},
+		{"de", 4, "Genitiv Singular"},
+		{"it", 4, "genitivo singolare"},
+		// 151: 1ère singulier indicatif futur antérieur actif
+		{"en", 151, "1st singular indicative future perfect active"},
+		{"de", 151, "1. Singular Indikativ Futur II Aktiv"},
+		{"en", 265, "supine in -um"},
+	}
+	for _, tt := range tests {
+		l.SetLabelLocale(tt.lang)
+		if got := l.Label(tt.m); got != tt.want {
+			t.Errorf("%q: Label(%d) = %q, want %q", tt.lang, tt.m, got, tt.want)
+		}
+	}
+
+	l.SetLabelLocale("it")
+	if slots := l.ModelParadigm("lupus"); len(slots) == 0 || slots[0].Label != "nominativo singolare" {
+		t.Errorf("lupus paradigm label in Italian: got %v", slots)
+	}
+}
+
+func TestDisambiguateText(t *testing.T) {
+	l, _ := New(dataDir)
+	descriptions := func(results []LemmatizationResult, key string) []string {
+		var out []string
+		for lemma, analyses := range results[1].Analyses {
+			if lemma.Key == key {
+				for _, a := range analyses {
+					out = append(out, a.MorphoDescription)
+				}
+			}
+		}
+		return out
+	}
+
+	// Without rules, DisambiguateText is LemmatizeText.
+	if got := descriptions(l.DisambiguateText("cum puella"), "puella"); len(got) != 3 {
+		t.Errorf("puella without rules: %q, want 3 analyses", got)
+	}
+
+	l.AddRule(PrepositionCaseRule{})
+	got := descriptions(l.DisambiguateText("cum puella"), "puella")
+	if len(got) != 1 || !strings.Contains(got[0], "ablatif") {
+		t.Errorf("cum puella: %q, want the ablative only", got)
+	}
+	// The rule never empties a token.
+	if r := l.DisambiguateText("ad amat"); len(r[1].Analyses) == 0 {
+		t.Error("ad amat: amat lost its analyses")
+	}
+}
+
+func TestLemmatizeTextDisambiguated(t *testing.T) {
+	l, _ := New(dataDir)
+	descriptions := func(res LemmatizationResult) []string {
+		var out []string
+		for _, analyses := range res.Analyses {
+			for _, a := range analyses {
+				out = append(out, a.MorphoDescription)
+			}
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	// magna agrees with puella in the singular only.
+	got := descriptions(l.LemmatizeTextDisambiguated("magna puella")[0])
+	want := []string{"ablatif féminin singulier", "nominatif féminin singulier", "vocatif féminin singulier"}
+	if !slices.Equal(got, want) {
+		t.Errorf("magna puella: magna %q, want %q", got, want)
+	}
+	if got := descriptions(l.LemmatizeText("magna puella")[0]); len(got) != 6 {
+		t.Errorf("LemmatizeText narrowed magna: %q", got)
+	}
+	// not across sentences
+	if got := descriptions(l.LemmatizeTextDisambiguated("magna. Puella")[0]); len(got) != 6 {
+		t.Errorf("magna. Puella: magna %q, want all 6 analyses", got)
+	}
+
+	// With puer as subject, cano is the verb rather than canus.
+	res := l.LemmatizeTextDisambiguated("Puer cano")
+	if len(res[1].Analyses) != 1 || res[1].Analyses[l.Lemma("cano")] == nil {
+		t.Errorf("Puer cano: cano %v, want the verb only", res[1].Analyses)
+	}
+	// A certain verb leaves the sentence alone.
+	if res := l.LemmatizeTextDisambiguated("Puer cano amat"); len(res[1].Analyses) != 2 {
+		t.Errorf("Puer cano amat: cano %v, want both lemmas", res[1].Analyses)
+	}
+
+	for _, res := range l.LemmatizeTextDisambiguated("Gallia est omnis divisa in partes tres") {
+		if len(res.Analyses) == 0 {
+			t.Errorf("%s lost its analyses", res.Token)
+		}
+	}
+}
+
+func TestLoadGlossesTSV(t *testing.T) {
+	l, _ := New(dataDir)
+	path := filepath.Join(t.TempDir(), "glosses.tsv")
+	tsv := "lupus\ten\ta wolf\n" +
+		"lupus\tla\tbestia\n" +
+		"nonexistentlemma\tfr\tx\n" +
+		"malformed row\n"
+	if err := os.WriteFile(path, []byte(tsv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := l.DataFingerprint()
+	nWarnings := len(l.Warnings())
+
+	if err := l.LoadGlossesTSV(path); err != nil {
+		t.Fatal(err)
+	}
+	lupus := l.Lemma("lupus")
+	if got := lupus.Translation("en"); got != "a wolf" {
+		t.Errorf("lupus en = %q, want %q", got, "a wolf")
+	}
+	if got := lupus.Translation("la"); got != "bestia" {
+		t.Errorf("lupus la = %q, want %q", got, "bestia")
+	}
+	if _, ok := l.Languages()["la"]; !ok {
+		t.Error("language la not registered")
+	}
+	if got := len(l.Warnings()) - nWarnings; got != 2 {
+		t.Errorf("got %d new warnings, want 2", got)
+	}
+	if l.DataFingerprint() == before {
+		t.Error("fingerprint unchanged after loading glosses")
+	}
+	if err := l.LoadGlossesTSV(filepath.Join(t.TempDir(), "missing.tsv")); err == nil {
+		t.Error("missing file: want an error")
+	}
+}
+
+func TestApplyOverlay(t *testing.T) {
+	l, _ := New(dataDir)
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	path := write("fixes.la", `! corrections
+add:lemma:cattus=cāttŭs|lupus|||i, m.|1
+override:lemma:rosa=rŏsŭs|lupus|||i, m.|63
+override:lemma:bōs|miles|bŏv||bovis, m.|243
+delete:lemma:puella
+delete:irreg:forem:sum
+
+! dea without the ending -abus
+override:model:dea
+pere:uita
+`)
+	rosaFr := l.Lemma("rosa").Translation("fr")
+	before := l.DataFingerprint()
+	if err := l.ApplyOverlay(path); err != nil {
+		t.Fatal(err)
+	}
+
+	has := func(form, key string) bool {
+		_, ok := l.LemmatizeWord(form, false)[l.Lemma(key)]
+		return ok
+	}
+	if !has("cattos", "cattus") {
+		t.Error("added cattus not found")
+	}
+	rosa := l.Lemma("rosa")
+	if !has("rosum", "rosa") || has("rosam", "rosa") || rosa.Translation("fr") != rosaFr {
+		t.Errorf("overridden rosa: rosum %v, rosam %v, fr %q", has("rosum", "rosa"), has("rosam", "rosa"), rosa.Translation("fr"))
+	}
+	// the irregular forms of an overridden lemma stay found
+	if !has("bobus", "bos") || !slices.Contains(l.InflectionTable(l.Lemma("bos")).Cells[11], "bōbŭs") {
+		t.Error("overridden bos: bobus lost")
+	}
+	if l.Lemma("puella") != nil || len(l.LemmatizeWord("puellam", false)) != 0 {
+		t.Error("deleted puella still found")
+	}
+	if has("forem", "sum") || !has("fores", "sum") {
+		t.Error("forem still an irregular form of sum, or fores lost")
+	}
+	if has("deabus", "dea") || !has("deam", "dea") {
+		t.Error("overridden model dea: deabus still found, or deam lost")
+	}
+	if l.DataFingerprint() == before {
+		t.Error("fingerprint unchanged")
+	}
+
+	for _, bad := range []string{
+		"add:lemma:lupus|lupus|||i, m.|1",
+		"override:lemma:nosuchlemma|lupus|||i, m.|1",
+		"delete:lemma:puella",
+		"add:lemma:nouus|nosuchmodel|||i, m.|1",
+		"delete:model:uita",
+		"replace:lemma:lupus",
+	} {
+		err := l.ApplyOverlay(write("bad.la", "! one bad line\n"+bad+"\n"))
+		if err == nil || !strings.Contains(err.Error(), "bad.la:2:") {
+			t.Errorf("%s: error %v, want one at bad.la:2", bad, err)
+		}
+	}
+}
+
+func TestNoDuplicateAnalysesAcrossPaths(t *testing.T) {
+	l, _ := New(dataDir)
+	// At the start of a sentence, Subiceretis is analysed both as written
+	// and lowercased, and subicio is reached again through the
+	// assimilation of sub-; the enclitic path goes through the same merges.
+	for _, form := range []string{"Subiceretis", "Subiceretisque", "Transicio"} {
+		for lemma, analyses := range l.LemmatizeWord(form, true) {
+			seen := make(map[string]bool)
+			for _, a := range analyses {
+				if seen[a.Key()] {
+					t.Errorf("%s: %s listed twice for %s", form, a.Key(), lemma.Key)
+				}
+				seen[a.Key()] = true
+			}
+		}
+	}
+}
+
+func TestNoDuplicateMorphos(t *testing.T) {
+	l, _ := New(dataDir)
+	for _, form := range []string{"puellae", "Corelli"} {
+		for lemma, analyses := range l.LemmatizeWord(form, false) {
+			seen := make(map[int]bool)
+			for _, a := range analyses {
+				if seen[a.MorphoIndex] {
+					t.Errorf("%s: morpho %d listed twice for %s", form, a.MorphoIndex, lemma.Key)
+				}
+				seen[a.MorphoIndex] = true
+			}
+		}
+	}
+}
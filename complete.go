@@ -0,0 +1,151 @@
+package collatinus
+
+import (
+	"sort"
+	"strings"
+)
+
+// Completion holds the candidates for a word being typed; see Complete.
+type Completion struct {
+	// Prefix is the typed prefix.
+	Prefix string
+	// Lemmas lists the lemmas having at least one form that starts with
+	// Prefix, most frequent first.
+	Lemmas []*Lemma
+	// Analyses holds the analyses of Prefix itself, when it is already a
+	// complete form.
+	Analyses map[*Lemma][]Analysis
+}
+
+// prefixEntry is one searchable string of the prefix index: a radical,
+// an irregular form or a canonical form, with the lemma it leads to.
+type prefixEntry struct {
+	key   string
+	lemma *Lemma
+}
+
+// buildPrefixIndex returns the radicals, irregular forms and canonical
+// forms of the lexicon (deramised, atone), sorted by key.
+func (l *Lemmatizer) buildPrefixIndex() []prefixEntry {
+	var entries []prefixEntry
+	for key, rads := range l.radicals {
+		for _, r := range rads {
+			entries = append(entries, prefixEntry{key, r.Lemma})
+		}
+	}
+	for key, irrs := range l.irregs {
+		for _, irr := range irrs {
+			entries = append(entries, prefixEntry{key, irr.Lemma})
+		}
+	}
+	for _, lemma := range l.lemmas {
+		entries = append(entries, prefixEntry{Deramise(lemma.Gr), lemma})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].key != entries[j].key {
+			return entries[i].key < entries[j].key
+		}
+		return entries[i].lemma.Key < entries[j].lemma.Key
+	})
+	return entries
+}
+
+// completions adds to set every lemma having a form that starts with p.
+func (l *Lemmatizer) completions(p string, set map[*Lemma]bool) {
+	// Radicals and forms starting with p.
+	i := sort.Search(len(l.prefixes), func(i int) bool {
+		return l.prefixes[i].key >= p
+	})
+	for ; i < len(l.prefixes) && strings.HasPrefix(l.prefixes[i].key, p); i++ {
+		set[l.prefixes[i].lemma] = true
+	}
+	// Radicals p starts with, when the rest of p begins one of their
+	// endings.
+	for n := 0; n <= len(p); n++ {
+		rest := p[n:]
+		for _, r := range l.radicals[p[:n]] {
+			if set[r.Lemma] {
+				continue
+			}
+			m := r.Lemma.Model()
+			if m == nil {
+				continue
+			}
+		desinences:
+			for _, ds := range m.Desinences {
+				for _, d := range ds {
+					if d.RadNum == r.Num && strings.HasPrefix(Deramise(d.Gr), rest) {
+						set[r.Lemma] = true
+						break desinences
+					}
+				}
+			}
+		}
+	}
+}
+
+// complete implements Complete.
+func (l *Lemmatizer) complete(prefix string, limit int) Completion {
+	p := NormalizeKey(prefix)
+	set := make(map[*Lemma]bool)
+	if p != "" {
+		l.completions(p, set)
+		if up := upperFirst(p); up != p {
+			l.completions(up, set)
+		}
+	}
+
+	lemmas := make([]*Lemma, 0, len(set))
+	for lemma := range set {
+		lemmas = append(lemmas, lemma)
+	}
+	sort.Slice(lemmas, func(i, j int) bool {
+		if lemmas[i].NbOcc != lemmas[j].NbOcc {
+			return lemmas[i].NbOcc > lemmas[j].NbOcc
+		}
+		return lemmas[i].Key < lemmas[j].Key
+	})
+	if limit > 0 && len(lemmas) > limit {
+		lemmas = lemmas[:limit]
+	}
+
+	var analyses map[*Lemma][]Analysis
+	if p != "" {
+		analyses = l.lemmatizeM(prefix, false)
+	}
+	return Completion{Prefix: prefix, Lemmas: lemmas, Analyses: analyses}
+}
+
+// sortLemmas returns the lemmas sorted by key, for lemmasWithPrefix.
+func (l *Lemmatizer) sortLemmas() []*Lemma {
+	lemmas := make([]*Lemma, 0, len(l.lemmas))
+	for _, lemma := range l.lemmas {
+		lemmas = append(lemmas, lemma)
+	}
+	sort.Slice(lemmas, func(i, j int) bool { return lemmas[i].Key < lemmas[j].Key })
+	return lemmas
+}
+
+// lemmasWithPrefix implements LemmasWithPrefix: the keys starting with
+// the prefix, and with it capitalized, are two runs of sortedLemmas.
+func (l *Lemmatizer) lemmasWithPrefix(prefix string, limit int) []*Lemma {
+	p := NormalizeKey(prefix)
+	queries := []string{p}
+	if up := upperFirst(p); up != p {
+		queries = append(queries, up)
+	}
+	var lemmas []*Lemma
+	for _, q := range queries {
+		i := sort.Search(len(l.sortedLemmas), func(i int) bool {
+			return l.sortedLemmas[i].Key >= q
+		})
+		for ; i < len(l.sortedLemmas) && strings.HasPrefix(l.sortedLemmas[i].Key, q); i++ {
+			lemmas = append(lemmas, l.sortedLemmas[i])
+		}
+	}
+	sort.Slice(lemmas, func(i, j int) bool { return lemmas[i].Key < lemmas[j].Key })
+	if limit > 0 && len(lemmas) > limit {
+		lemmas = lemmas[:limit]
+	}
+	return lemmas
+}
@@ -0,0 +1,50 @@
+package collatinus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// exportCoNLLU implements ExportCoNLLU.
+func (l *Lemmatizer) exportCoNLLU(results []LemmatizationResult, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	sent, id := 0, 0
+	for _, res := range results {
+		if res.SentenceStart || sent == 0 {
+			if sent > 0 {
+				bw.WriteString("\n")
+			}
+			sent, id = sent+1, 0
+			fmt.Fprintf(bw, "# sent_id = %d\n", sent)
+		}
+		id++
+		lemmas := l.rankLemmas(res.Analyses)
+		lemma, upos, feats, misc := res.Token, "X", "_", "_"
+		if len(lemmas) > 0 {
+			best := lemmas[0]
+			lemma, upos = best.Gr, UDTag(best)
+			if as := res.Analyses[best]; len(as) > 0 {
+				if f := udFeats(as[0].MorphoDescription); f != "" {
+					feats = f
+				}
+			}
+			var alts []string
+			for _, other := range lemmas[1:] {
+				if other.Gr != lemma && !slices.Contains(alts, other.Gr) {
+					alts = append(alts, other.Gr)
+				}
+			}
+			if len(alts) > 0 {
+				misc = "AltLemmas=" + strings.Join(alts, ",")
+			}
+		}
+		fmt.Fprintf(bw, "%d\t%s\t%s\t%s\t_\t%s\t_\t_\t_\t%s\n", id, res.Token, lemma, upos, feats, misc)
+	}
+	if sent > 0 {
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
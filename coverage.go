@@ -0,0 +1,38 @@
+package collatinus
+
+import "sort"
+
+// coverageVocabulary implements CoverageVocabulary.
+func (l *Lemmatizer) coverageVocabulary(text string, targetPct float64) []*Lemma {
+	results := l.disambiguateText(text)
+	counts := map[*Lemma]int{}
+	for _, res := range results {
+		if lemma, _ := l.bestAnalysis(res.Analyses); lemma != nil {
+			counts[lemma]++
+		}
+	}
+	lemmas := make([]*Lemma, 0, len(counts))
+	for lemma := range counts {
+		lemmas = append(lemmas, lemma)
+	}
+	sort.Slice(lemmas, func(i, j int) bool {
+		a, b := lemmas[i], lemmas[j]
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		if a.NbOcc != b.NbOcc {
+			return a.NbOcc > b.NbOcc
+		}
+		return a.Key < b.Key
+	})
+
+	target := targetPct / 100 * float64(len(results))
+	covered := 0
+	for i, lemma := range lemmas {
+		if float64(covered) >= target {
+			return lemmas[:i]
+		}
+		covered += counts[lemma]
+	}
+	return lemmas
+}
@@ -0,0 +1,60 @@
+package collatinus
+
+import "sort"
+
+// LemmaAnalysis is an analysis together with the key of its lemma.
+type LemmaAnalysis struct {
+	LemmaKey string
+	Analysis Analysis
+}
+
+// Diff is a word whose analyses differ between two Lemmatizers; see
+// DiffLemmatize.
+type Diff struct {
+	Word string
+	// Removed lists the analyses only the first Lemmatizer finds, Added
+	// those only the second finds, by lemma key and analysis key.
+	Removed, Added []LemmaAnalysis
+}
+
+// diffLemmatize implements DiffLemmatize.
+func (l *Lemmatizer) diffLemmatize(other *Lemmatizer, words []string) []Diff {
+	var diffs []Diff
+	for _, w := range words {
+		before := analysisSet(l.lemmatizeM(w, false))
+		after := analysisSet(other.lemmatizeM(w, false))
+		d := Diff{Word: w, Removed: subtractAnalyses(before, after), Added: subtractAnalyses(after, before)}
+		if len(d.Removed) > 0 || len(d.Added) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// analysisSet keys the analyses of mm by lemma key and Analysis.Key, which
+// stay the same across Lemmatizers loaded from the same data.
+func analysisSet(mm map[*Lemma][]Analysis) map[string]LemmaAnalysis {
+	set := make(map[string]LemmaAnalysis)
+	for lemma, analyses := range mm {
+		for _, a := range analyses {
+			set[lemma.Key+" "+a.Key()] = LemmaAnalysis{LemmaKey: lemma.Key, Analysis: a}
+		}
+	}
+	return set
+}
+
+// subtractAnalyses returns the analyses of a missing from b, in key order.
+func subtractAnalyses(a, b map[string]LemmaAnalysis) []LemmaAnalysis {
+	var keys []string
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]LemmaAnalysis, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, a[k])
+	}
+	return out
+}
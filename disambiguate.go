@@ -0,0 +1,248 @@
+package collatinus
+
+import (
+	"slices"
+	"strings"
+)
+
+// DisambiguationRule narrows down the analyses of a token from the
+// analyses of the token before it. Rules are registered with AddRule and
+// applied in order by DisambiguateText.
+type DisambiguationRule interface {
+	// Name identifies the rule, e.g. in logs.
+	Name() string
+	// Apply returns the analyses of cur to keep, given the previous token
+	// (prev.Analyses is nil for the first token of the text). Returning
+	// an empty map keeps cur unchanged, so a rule can never leave a known
+	// word without analyses.
+	Apply(prev, cur LemmatizationResult) map[*Lemma][]Analysis
+}
+
+// PrepositionCaseRule is a built-in DisambiguationRule: after a
+// preposition, it keeps the analyses in the case the preposition governs
+// ("prép. + acc." → accusative, "prép. + abl." → ablative, both when the
+// lexicon does not say), so that "cum puella" reads puella as an ablative.
+type PrepositionCaseRule struct{}
+
+// Name implements DisambiguationRule.
+func (PrepositionCaseRule) Name() string {
+	return "preposition-case"
+}
+
+// Apply implements DisambiguationRule.
+func (PrepositionCaseRule) Apply(prev, cur LemmatizationResult) map[*Lemma][]Analysis {
+	var cases []string
+	for lemma := range prev.Analyses {
+		if lemma.POS != POSPreposition {
+			continue
+		}
+		acc := strings.Contains(lemma.IndMorph, "acc.")
+		abl := strings.Contains(lemma.IndMorph, "abl.")
+		if acc || !abl {
+			cases = append(cases, "accusatif")
+		}
+		if abl || !acc {
+			cases = append(cases, "ablatif")
+		}
+	}
+	if len(cases) == 0 {
+		return nil
+	}
+
+	kept := make(map[*Lemma][]Analysis)
+	for lemma, analyses := range cur.Analyses {
+		for _, a := range analyses {
+			for _, c := range cases {
+				if strings.Contains(a.MorphoDescription, c) {
+					kept[lemma] = append(kept[lemma], a)
+					break
+				}
+			}
+		}
+	}
+	return kept
+}
+
+// disambiguateText lemmatizes text and runs the registered rules over
+// each token, left to right, each rule seeing the already disambiguated
+// previous token.
+func (l *Lemmatizer) disambiguateText(text string) []LemmatizationResult {
+	results := l.lemmatizeText(text)
+	for i := range results {
+		var prev LemmatizationResult
+		if i > 0 {
+			prev = results[i-1]
+		}
+		for _, r := range l.rules {
+			if kept := r.Apply(prev, results[i]); len(kept) > 0 {
+				results[i].Analyses = kept
+			}
+		}
+	}
+	return results
+}
+
+// lemmatizeTextDisambiguated implements LemmatizeTextDisambiguated. Both
+// passes read the candidates LemmatizeText gives, so the result does not
+// depend on the order the tokens are narrowed in.
+func (l *Lemmatizer) lemmatizeTextDisambiguated(text string) []LemmatizationResult {
+	results := l.lemmatizeText(text)
+	narrowed := make([]map[*Lemma][]Analysis, len(results))
+	for i := range results {
+		narrowed[i] = agreeingAnalyses(results, i)
+	}
+	for i, mm := range narrowed {
+		if mm != nil {
+			results[i].Analyses = mm
+		}
+	}
+	for start := 0; start < len(results); {
+		end := start + 1
+		for end < len(results) && !results[end].SentenceStart {
+			end++
+		}
+		preferFiniteVerbs(results[start:end])
+		start = end
+	}
+	return results
+}
+
+// adjectival reports whether a, an analysis of lemma, declines like an
+// adjective: that of an adjective, a pronoun or a participle.
+func adjectival(lemma *Lemma, a Analysis) bool {
+	return lemma.POS == POSAdjective || lemma.POS == POSPronoun || a.Morphology.Mood == MoodParticiple
+}
+
+// agree reports whether two declined readings agree in case, number
+// and, when both give it, gender.
+func agree(a, b Morphology) bool {
+	return a.Case == b.Case && a.Number == b.Number &&
+		(a.Gender == GenderUnspecified || b.Gender == GenderUnspecified || a.Gender == b.Gender)
+}
+
+// agreeingAnalyses returns the analyses of results[i] without the
+// declined readings of each lemma that agree with none of the tokens
+// next to it in the sentence, when the lemma has some that do; an
+// adjective agrees with a noun or another adjective, a noun only with an
+// adjective. It returns nil when nothing is dropped.
+func agreeingAnalyses(results []LemmatizationResult, i int) map[*Lemma][]Analysis {
+	type reading struct {
+		m          Morphology
+		adjectival bool
+	}
+	var near []reading
+	for _, j := range []int{i - 1, i + 1} {
+		if j < 0 || j >= len(results) || results[max(i, j)].SentenceStart {
+			continue
+		}
+		for lemma, analyses := range results[j].Analyses {
+			for _, a := range analyses {
+				if a.Morphology.Case != CaseUnspecified {
+					near = append(near, reading{a.Morphology, adjectival(lemma, a)})
+				}
+			}
+		}
+	}
+	if len(near) == 0 {
+		return nil
+	}
+
+	kept := make(map[*Lemma][]Analysis, len(results[i].Analyses))
+	dropped := false
+	for lemma, analyses := range results[i].Analyses {
+		agrees := func(a Analysis) bool {
+			if a.Morphology.Case == CaseUnspecified {
+				return false
+			}
+			for _, r := range near {
+				if (r.adjectival || adjectival(lemma, a)) && agree(a.Morphology, r.m) {
+					return true
+				}
+			}
+			return false
+		}
+		if !slices.ContainsFunc(analyses, agrees) {
+			kept[lemma] = analyses
+			continue
+		}
+		for _, a := range analyses {
+			if a.Morphology.Case == CaseUnspecified || agrees(a) {
+				kept[lemma] = append(kept[lemma], a)
+			} else {
+				dropped = true
+			}
+		}
+	}
+	if !dropped {
+		return nil
+	}
+	return kept
+}
+
+// finite reports whether a is a finite verb form, which has a person.
+func finite(a Analysis) bool {
+	return a.Morphology.Person != 0
+}
+
+// preferFiniteVerbs narrows down the sentence sent when it has a subject,
+// a word only read as a nominative (or vocative), but no certain verb:
+// if a single word can be a finite verb, its finite readings are kept,
+// those agreeing in number with the subject when some do.
+func preferFiniteVerbs(sent []LemmatizationResult) {
+	var numbers []Number
+	candidate := -1
+	for i, res := range sent {
+		var nominative, other, verb, nonVerb bool
+		for _, analyses := range res.Analyses {
+			for _, a := range analyses {
+				switch {
+				case finite(a):
+					verb = true
+				case a.Morphology.Case == CaseNominative:
+					nominative, nonVerb = true, true
+				case a.Morphology.Case == CaseVocative:
+					nonVerb = true
+				default:
+					other, nonVerb = true, true
+				}
+			}
+		}
+		switch {
+		case verb && !nonVerb:
+			return
+		case verb:
+			if candidate >= 0 {
+				return
+			}
+			candidate = i
+		case nominative && !other:
+			for _, analyses := range res.Analyses {
+				for _, a := range analyses {
+					if a.Morphology.Case == CaseNominative {
+						numbers = append(numbers, a.Morphology.Number)
+					}
+				}
+			}
+		}
+	}
+	if candidate < 0 || len(numbers) == 0 {
+		return
+	}
+
+	keep := func(agreeing bool) map[*Lemma][]Analysis {
+		kept := make(map[*Lemma][]Analysis)
+		for lemma, analyses := range sent[candidate].Analyses {
+			for _, a := range analyses {
+				if finite(a) && (!agreeing || slices.Contains(numbers, a.Morphology.Number)) {
+					kept[lemma] = append(kept[lemma], a)
+				}
+			}
+		}
+		return kept
+	}
+	kept := keep(true)
+	if len(kept) == 0 {
+		kept = keep(false)
+	}
+	sent[candidate].Analyses = kept
+}
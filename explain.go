@@ -0,0 +1,280 @@
+package collatinus
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// inflectionClass is the declension or conjugation of the lemmas whose
+// model is, or inherits from, model.
+type inflectionClass struct {
+	model string
+	// n is the declension or conjugation number; 0 for irregular verbs.
+	n int
+}
+
+// nounClasses, adjectiveClasses and verbClasses list the inflection
+// classes by model, the most specific first: fortis inherits from doctus,
+// moneo from amo and sum from lego.
+var (
+	nounClasses = []inflectionClass{
+		{"uita", 1}, {"lupus", 2}, {"miles", 3}, {"manus", 4}, {"res", 5},
+	}
+	adjectiveClasses = []inflectionClass{
+		{"fortis", 2}, {"doctus", 1},
+	}
+	verbClasses = []inflectionClass{
+		{"sum", 0}, {"fero", 0}, {"uolo", 0}, {"edo", 0}, {"eo", 0}, {"fio", 0}, {"aio", 0},
+		{"audio", 4}, {"capio", 3}, {"lego", 3}, {"moneo", 2}, {"amo", 1},
+		{"potior", 4}, {"patior", 3}, {"sequor", 3}, {"uereor", 2}, {"imitor", 1},
+	}
+)
+
+// classOf returns the inflection class number of lemma among classes,
+// and false when its model is none of them.
+func classOf(lemma *Lemma, classes []inflectionClass) (int, bool) {
+	if lemma.model == nil {
+		return 0, false
+	}
+	for _, c := range classes {
+		if lemma.model.EstUn(c.model) {
+			return c.n, true
+		}
+	}
+	return 0, false
+}
+
+// caseWords are the case names that open the morpho descriptions.
+var caseWords = []string{"nominatif", "vocatif", "accusatif", "génitif", "datif", "ablatif", "locatif"}
+
+// explanation holds the words of an explaining sentence in one language.
+type explanation struct {
+	// is formats the sentence from the form, the labels, the lemma with
+	// its gloss (after of) and the class; isBare leaves out the labels.
+	is, isBare string
+	of         func(name string) string
+	or         string
+	pos        map[PartOfSpeech]string
+	genders    map[string]string
+	// class formats the class from the part of speech and its name, the
+	// genders, the class number (-1 for none) and its ordinal, and
+	// whether the verb is deponent.
+	class func(pos PartOfSpeech, name, genders string, n int, ordinal string, deponent bool) string
+}
+
+var explanations = map[string]explanation{
+	"en": {
+		is:     "%s is the %s %s, %s.",
+		isBare: "%s is %s, %s.",
+		of:     func(name string) string { return "of " + name },
+		or:     " or ",
+		pos: map[PartOfSpeech]string{
+			POSNoun: "noun", POSVerb: "verb", POSAdjective: "adjective",
+			POSPronoun: "pronoun", POSAdverb: "adverb", POSConjunction: "conjunction",
+			POSExclamation: "exclamation", POSInterjection: "interjection",
+			POSNumeral: "numeral", POSPreposition: "preposition", POSUnknown: "word",
+		},
+		genders: map[string]string{"m": "masculine", "f": "feminine", "n": "neuter"},
+		class: func(pos PartOfSpeech, name, genders string, n int, ordinal string, deponent bool) string {
+			words := []string{name}
+			if deponent {
+				words = []string{"deponent", name}
+			}
+			if genders != "" {
+				words = append([]string{genders}, words...)
+			}
+			switch {
+			case n < 0:
+			case n == 0:
+				words = append([]string{"irregular"}, words...)
+			case pos == POSVerb:
+				words = append([]string{ordinal + "-conjugation"}, words...)
+			case pos == POSAdjective && n == 1:
+				words = append([]string{"1st/2nd-declension"}, words...)
+			case pos == POSAdjective:
+				words = append([]string{"3rd-declension"}, words...)
+			default:
+				words = append([]string{ordinal + "-declension"}, words...)
+			}
+			article := "a"
+			if strings.ContainsRune("aeiou", rune(words[0][0])) {
+				article = "an"
+			}
+			return article + " " + strings.Join(words, " ")
+		},
+	},
+	"fr": {
+		is:     "%s : %s %s, %s.",
+		isBare: "%s : %s, %s.",
+		of: func(name string) string {
+			if strings.ContainsRune("aeiouyhAEIOUYH", []rune(name)[0]) {
+				return "d'" + name
+			}
+			return "de " + name
+		},
+		or: " ou ",
+		pos: map[PartOfSpeech]string{
+			POSNoun: "nom", POSVerb: "verbe", POSAdjective: "adjectif",
+			POSPronoun: "pronom", POSAdverb: "adverbe", POSConjunction: "conjonction",
+			POSExclamation: "exclamation", POSInterjection: "interjection",
+			POSNumeral: "numéral", POSPreposition: "préposition", POSUnknown: "mot",
+		},
+		genders: map[string]string{"m": "masculin", "f": "féminin", "n": "neutre"},
+		class: func(pos PartOfSpeech, name, genders string, n int, ordinal string, deponent bool) string {
+			words := []string{name}
+			if genders != "" {
+				words = append(words, genders)
+			}
+			if deponent {
+				words = append(words, "déponent")
+			}
+			switch {
+			case n < 0:
+			case n == 0:
+				words = append(words, "irrégulier")
+			case pos == POSVerb:
+				words = append(words, "de la "+ordinal+" conjugaison")
+			case pos == POSAdjective:
+				words = append(words, "de la "+ordinal+" classe")
+			default:
+				words = append(words, "de la "+ordinal+" déclinaison")
+			}
+			return strings.Join(words, " ")
+		},
+	},
+}
+
+// ordinals holds the ordinal abbreviations of 1 to 5 by language.
+var ordinals = map[string][]string{
+	"en": {"", "1st", "2nd", "3rd", "4th", "5th"},
+	"fr": {"", "1re", "2e", "3e", "4e", "5e"},
+}
+
+// explain implements Explain.
+func (l *Lemmatizer) explain(form, lang string) []string {
+	mm := l.lemmatizeM(form, false)
+	lemmas := l.rankLemmas(mm)
+
+	sentLang := "en"
+	if lang == "fr" {
+		sentLang = "fr"
+	}
+	ex := explanations[sentLang]
+	out := make([]string, 0, len(lemmas))
+	for _, lemma := range lemmas {
+		name := lemma.Gr
+		if gloss := firstSense(lemma.Translation(lang)); gloss != "" {
+			name += " (" + gloss + ")"
+		}
+		class := l.explainClass(lemma, ex, sentLang)
+		if labels := l.explainLabels(lemma, mm[lemma], sentLang); labels != "" {
+			out = append(out, fmt.Sprintf(ex.is, form, labels, ex.of(name), class))
+		} else {
+			out = append(out, fmt.Sprintf(ex.isBare, form, name, class))
+		}
+	}
+	return out
+}
+
+// explainLabels joins the morpho labels of analyses in lang, merging the
+// cases of otherwise identical labels: "genitive singular or
+// nominative/vocative plural". Invariable analyses have no label.
+func (l *Lemmatizer) explainLabels(lemma *Lemma, analyses []Analysis, lang string) string {
+	// groups are labels without their case, with the cases they take
+	type group struct {
+		rest  string
+		cases []string
+	}
+	analyses = slices.Clone(analyses)
+	sort.SliceStable(analyses, func(i, j int) bool {
+		return analyses[i].MorphoIndex < analyses[j].MorphoIndex
+	})
+	var groups []*group
+	for _, a := range analyses {
+		words := strings.Fields(l.MorphoOf(lemma, a.MorphoIndex))
+		if len(words) == 0 || words[0] == "inv." {
+			continue
+		}
+		c := ""
+		if slices.Contains(caseWords, words[0]) {
+			c, words = words[0], words[1:]
+		}
+		rest := strings.Join(words, " ")
+		i := slices.IndexFunc(groups, func(g *group) bool { return g.rest == rest })
+		if i < 0 {
+			i = len(groups)
+			groups = append(groups, &group{rest: rest})
+		}
+		if c != "" && !slices.Contains(groups[i].cases, c) {
+			groups[i].cases = append(groups[i].cases, c)
+		}
+	}
+
+	translate := func(s string) string {
+		if table, ok := labelTables[lang]; ok {
+			return translateLabel(s, table)
+		}
+		return s
+	}
+	labels := make([]string, 0, len(groups))
+	for _, g := range groups {
+		var label []string
+		if len(g.cases) > 0 {
+			for i, c := range g.cases {
+				g.cases[i] = translate(c)
+			}
+			label = append(label, strings.Join(g.cases, "/"))
+		}
+		if g.rest != "" {
+			label = append(label, translate(g.rest))
+		}
+		labels = append(labels, strings.Join(label, " "))
+	}
+	return strings.Join(labels, explanations[lang].or)
+}
+
+// explainClass describes the part of speech, gender and declension or
+// conjugation of lemma.
+func (l *Lemmatizer) explainClass(lemma *Lemma, ex explanation, lang string) string {
+	pos := lemma.POS
+	if _, ok := ex.pos[pos]; !ok {
+		pos = POSUnknown
+	}
+	n, found := -1, false
+	switch pos {
+	case POSNoun:
+		n, found = classOf(lemma, nounClasses)
+	case POSAdjective:
+		n, found = classOf(lemma, adjectiveClasses)
+	case POSVerb:
+		n, found = classOf(lemma, verbClasses)
+	}
+	if !found {
+		n = -1
+	}
+	ordinal := ""
+	if n > 0 {
+		ordinal = ordinals[lang][n]
+	}
+
+	var genders []string
+	if pos == POSNoun {
+		for _, g := range []string{"m", "f", "n"} {
+			if slices.Contains(strings.Fields(strings.ReplaceAll(lemma.IndMorph, ",", " ")), g+".") {
+				genders = append(genders, ex.genders[g])
+			}
+		}
+	}
+	return ex.class(pos, ex.pos[pos], strings.Join(genders, "/"), n, ordinal, lemma.Deponent)
+}
+
+// firstSense returns the first sense of a gloss, up to its first
+// semicolon.
+func firstSense(gloss string) string {
+	if i := strings.Index(gloss, ";"); i >= 0 {
+		gloss = gloss[:i]
+	}
+	return strings.TrimSpace(gloss)
+}
@@ -0,0 +1,77 @@
+package collatinus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// computeFingerprint hashes the loaded morphos, models, lemmas (with their
+// translations) and irregular forms in a canonical order, so that two
+// processes loading identical data get the same fingerprint.
+func (l *Lemmatizer) computeFingerprint() string {
+	h := sha256.New()
+
+	for i, m := range l.morphos {
+		fmt.Fprintf(h, "M%d:%s\n", i, m)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(l.models)) {
+		m := l.models[name]
+		parent := ""
+		if m.parent != nil {
+			parent = m.parent.Name
+		}
+		fmt.Fprintf(h, "m:%s:%s:%c:%v\n", m.Name, parent, m.pos, m.Absents)
+		rns := make([]int, 0, len(m.RadicalRules))
+		for rn := range m.RadicalRules {
+			rns = append(rns, rn)
+		}
+		sort.Ints(rns)
+		for _, rn := range rns {
+			fmt.Fprintf(h, "R:%d:%s\n", rn, m.RadicalRules[rn])
+		}
+		mns := make([]int, 0, len(m.Desinences))
+		for mn := range m.Desinences {
+			mns = append(mns, mn)
+		}
+		sort.Ints(mns)
+		for _, mn := range mns {
+			for _, d := range m.Desinences[mn] {
+				fmt.Fprintf(h, "d:%d:%d:%s\n", mn, d.RadNum, d.Grq)
+			}
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(l.lemmas)) {
+		lemma := l.lemmas[key]
+		fmt.Fprintf(h, "l:%s:%s:%s:%s:%d\n", key, lemma.Grq, lemma.modelName, lemma.IndMorph, lemma.NbOcc)
+		for _, lang := range slices.Sorted(maps.Keys(lemma.translations)) {
+			fmt.Fprintf(h, "t:%s:%s\n", lang, lemma.translations[lang])
+		}
+		for _, irr := range lemma.irregs {
+			fmt.Fprintf(h, "i:%s:%v:%v\n", irr.Grq, irr.Exclusive, irr.Morphos)
+		}
+		writeRadicals(h, lemma)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeRadicals feeds the radicals of lemma to h, by radical number.
+func writeRadicals(h hash.Hash, lemma *Lemma) {
+	rns := make([]int, 0, len(lemma.radicals))
+	for rn := range lemma.radicals {
+		rns = append(rns, rn)
+	}
+	sort.Ints(rns)
+	for _, rn := range rns {
+		for _, r := range lemma.radicals[rn] {
+			fmt.Fprintf(h, "r:%d:%s\n", rn, r.Grq)
+		}
+	}
+}
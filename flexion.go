@@ -1,5 +1,86 @@
 package collatinus
 
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParadigmSlot is one morpho cell of a model's paradigm; see ModelParadigm.
+type ParadigmSlot struct {
+	// MorphoIndex is the 1-based morpho index of the cell.
+	MorphoIndex int
+	// Label is the morpho description in the label locale (e.g. "génitif
+	// singulier"); see SetLabelLocale.
+	Label string
+	// Endings lists the desinences of the cell, the primary one first.
+	Endings []*Desinence
+	// Forms are the endings after a placeholder stem: "-ŭs", or "1-īstī"
+	// (the radical number before the dash) for models with several
+	// radicals.
+	Forms []string
+}
+
+// MergedInflectionTable is the inflection table of the homonyms of a key
+// that share a model, as one table for display; see
+// MergedInflectionTable.
+type MergedInflectionTable struct {
+	// Lemmas are the merged homonyms, by homonym number.
+	Lemmas []*Lemma
+	// Cells maps morpho index (1-based) to the cell of the homonyms.
+	Cells map[int]MergedCell
+}
+
+// MergedCell is a cell of a MergedInflectionTable.
+type MergedCell struct {
+	// Forms lists the forms of the cell: those of every homonym when they
+	// agree, else the forms of any of them, without repeats.
+	Forms []string
+	// ByLemma is nil when the homonyms agree, and else holds the forms of
+	// each one, none for a homonym lacking the cell.
+	ByLemma map[*Lemma][]string
+}
+
+// GeneratedForm is one surface form produced by inflecting a lemma.
+type GeneratedForm struct {
+	// Lemma is the inflected lemma.
+	Lemma *Lemma
+	// Grq is the form with vowel-quantity marks.
+	Grq string
+	// Gr is the form without quantity marks, keeping its j/v spelling
+	// (Atone(Grq)).
+	Gr string
+	// Key is the fully folded form (NormalizeKey(Grq)), suitable for
+	// matching unmarked user input.
+	Key string
+	// MorphoIndex is the 1-based morpho index the form realizes.
+	MorphoIndex int
+}
+
+// AmbiguousForm is a surface form that inflects more than one lemma.
+type AmbiguousForm struct {
+	// Form is the form without quantity marks (GeneratedForm.Gr).
+	Form string
+	// Lemmas lists the competing lemmas, by key.
+	Lemmas []*Lemma
+}
+
+// cachedInflectionTable implements InflectionTable, computing the table
+// of each lemma once.
+func (l *Lemmatizer) cachedInflectionTable(lemma *Lemma) *InflectionTable {
+	if table, ok := l.tables.Load(lemma); ok {
+		return table.(*InflectionTable)
+	}
+	table := l.inflectionTable(lemma)
+	if table != nil {
+		l.tables.Store(lemma, table)
+	}
+	return table
+}
+
 // inflectionTable computes the full inflection table for a lemma.
 // Mirrors Flexion::forme and the tableau* functions in flexion.cpp.
 func (l *Lemmatizer) inflectionTable(lemma *Lemma) *InflectionTable {
@@ -16,17 +97,73 @@ func (l *Lemmatizer) inflectionTable(lemma *Lemma) *InflectionTable {
 		Cells: make(map[int][]string),
 	}
 
-	// Collect all morpho indices defined by the model
+	// Collect all morpho indices defined by the model, and those only
+	// irregular forms give, such as the locative of humus
 	for mn := range m.Desinences {
 		forms := l.inflectedForms(lemma, mn)
 		if len(forms) > 0 {
 			table.Cells[mn] = forms
 		}
 	}
+	for _, irr := range lemma.irregs {
+		for _, mn := range irr.Morphos {
+			if _, ok := table.Cells[mn]; !ok {
+				if forms := l.inflectedForms(lemma, mn); len(forms) > 0 {
+					table.Cells[mn] = forms
+				}
+			}
+		}
+	}
 
 	return table
 }
 
+// mergedInflectionTable implements MergedInflectionTable.
+func (l *Lemmatizer) mergedInflectionTable(key string) *MergedInflectionTable {
+	base := strings.TrimRight(NormalizeKey(key), "0123456789")
+	var tables []*InflectionTable
+	for _, suffix := range []string{"", "1", "2", "3", "4", "5", "6", "7", "8", "9"} {
+		lemma := l.lemmas[base+suffix]
+		if lemma == nil || lemma.model == nil || (len(tables) > 0 && lemma.model != tables[0].Lemma.model) {
+			continue
+		}
+		tables = append(tables, l.inflectionTable(lemma))
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	merged := &MergedInflectionTable{Cells: make(map[int]MergedCell)}
+	for _, t := range tables {
+		merged.Lemmas = append(merged.Lemmas, t.Lemma)
+	}
+	for _, t := range tables {
+		for mn := range t.Cells {
+			if _, done := merged.Cells[mn]; done {
+				continue
+			}
+			var cell MergedCell
+			for _, u := range tables {
+				if !slices.Equal(u.Cells[mn], t.Cells[mn]) {
+					cell.ByLemma = make(map[*Lemma][]string)
+				}
+			}
+			for _, u := range tables {
+				for _, f := range u.Cells[mn] {
+					if !slices.Contains(cell.Forms, f) {
+						cell.Forms = append(cell.Forms, f)
+					}
+				}
+				if cell.ByLemma != nil && len(u.Cells[mn]) > 0 {
+					cell.ByLemma[u.Lemma] = u.Cells[mn]
+				}
+			}
+			merged.Cells[mn] = cell
+		}
+	}
+	return merged
+}
+
 // inflectedForms returns the list of inflected forms for a lemma at morpho index n.
 // Mirrors Flexion::forme in flexion.cpp.
 func (l *Lemmatizer) inflectedForms(lemma *Lemma, morphoIdx int) []string {
@@ -54,18 +191,156 @@ func (l *Lemmatizer) inflectedForms(lemma *Lemma, morphoIdx int) []string {
 		forms = append(forms, irreqGrq)
 	}
 
-	// Regular forms: for each desinence at this morpho, for each matching radical
+	// Regular forms: for each desinence at this morpho, for each matching
+	// radical, primary desinences first and variants after.
+	var variants []string
 	for _, d := range m.DesinencesAt(morphoIdx) {
 		for _, rad := range lemma.RadicalsAt(d.RadNum) {
-			forms = append(forms, rad.Grq+d.Grq)
+			if d.Variant {
+				variants = append(variants, rad.Grq+d.Grq)
+			} else {
+				forms = append(forms, rad.Grq+d.Grq)
+			}
 		}
 	}
+	forms = append(forms, variants...)
 
 	// Deduplicate
 	forms = unique(forms)
 	return forms
 }
 
+// modelParadigm lists the cells of the named model in morpho order, with
+// their endings after a placeholder stem.
+func (l *Lemmatizer) modelParadigm(name string) []ParadigmSlot {
+	m := l.models[name]
+	if m == nil {
+		return nil
+	}
+
+	radNums := make(map[int]bool)
+	mns := make([]int, 0, len(m.Desinences))
+	for mn, ds := range m.Desinences {
+		mns = append(mns, mn)
+		for _, d := range ds {
+			radNums[d.RadNum] = true
+		}
+	}
+	sort.Ints(mns)
+
+	slots := make([]ParadigmSlot, 0, len(mns))
+	for _, mn := range mns {
+		slot := ParadigmSlot{MorphoIndex: mn, Label: l.Label(mn)}
+		for _, d := range m.DesinencesAt(mn) {
+			stem := "-"
+			if len(radNums) > 1 {
+				stem = strconv.Itoa(d.RadNum) + stem
+			}
+			slot.Endings = append(slot.Endings, d)
+			slot.Forms = append(slot.Forms, stem+d.Grq)
+		}
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// inflectStem implements InflectStem.
+func (l *Lemmatizer) inflectStem(stem, modelName string) (*InflectionTable, error) {
+	m := l.models[modelName]
+	if m == nil {
+		return nil, fmt.Errorf("unknown model %q", modelName)
+	}
+	if strings.TrimSpace(stem) == "" || strings.ContainsAny(stem, "|=,") {
+		return nil, fmt.Errorf("invalid stem %q", stem)
+	}
+	lemma := newLemma(strings.TrimSpace(stem) + "|" + modelName + "|||")
+	lemma.model = m
+	if lemma.POS = m.POS(); lemma.POS != POSUnknown {
+		lemma.posSource = POSFromModel
+	}
+	lemma.Deponent = isDeponent(lemma)
+	deriveRadicals(lemma)
+	return l.inflectionTable(lemma), nil
+}
+
+// generateAllForms inflects every lemma of the lexicon, in key order and
+// then morpho order, and calls fn for each generated form.
+func (l *Lemmatizer) generateAllForms(fn func(GeneratedForm)) {
+	for _, k := range slices.Sorted(maps.Keys(l.lemmas)) {
+		lemma := l.lemmas[k]
+		table := l.inflectionTable(lemma)
+		if table == nil {
+			continue
+		}
+		mns := make([]int, 0, len(table.Cells))
+		for mn := range table.Cells {
+			mns = append(mns, mn)
+		}
+		sort.Ints(mns)
+		for _, mn := range mns {
+			for _, f := range table.Cells[mn] {
+				fn(GeneratedForm{
+					Lemma:       lemma,
+					Grq:         f,
+					Gr:          Atone(f),
+					Key:         NormalizeKey(f),
+					MorphoIndex: mn,
+				})
+			}
+		}
+	}
+}
+
+// eachAmbiguousForm buckets the generated forms by their unmarked
+// spelling and calls fn, in form order, for each one shared by several
+// lemmas, until fn returns false. The buckets of the whole lexicon are
+// built before the first call.
+func (l *Lemmatizer) eachAmbiguousForm(fn func(AmbiguousForm) bool) {
+	buckets := make(map[string][]*Lemma)
+	l.generateAllForms(func(g GeneratedForm) {
+		// lemmas come in key order: a repeat is the last one added
+		b := buckets[g.Gr]
+		if len(b) == 0 || b[len(b)-1] != g.Lemma {
+			buckets[g.Gr] = append(b, g.Lemma)
+		}
+	})
+	for _, form := range slices.Sorted(maps.Keys(buckets)) {
+		if lemmas := buckets[form]; len(lemmas) > 1 {
+			if !fn(AmbiguousForm{Form: form, Lemmas: lemmas}) {
+				return
+			}
+		}
+	}
+}
+
+// syncreticForms maps each form of the lemma's inflection table to the
+// sorted list of morpho indices it realizes, keeping only forms that
+// realize more than one cell (e.g. pŭēllāe → genitive and dative singular,
+// nominative and vocative plural).
+func (l *Lemmatizer) syncreticForms(lemma *Lemma) map[string][]int {
+	table := l.inflectionTable(lemma)
+	if table == nil {
+		return nil
+	}
+
+	cells := make(map[string][]int)
+	for mn, forms := range table.Cells {
+		for _, f := range forms {
+			cells[f] = append(cells[f], mn)
+		}
+	}
+
+	out := make(map[string][]int)
+	for f, mns := range cells {
+		if len(mns) < 2 {
+			continue
+		}
+		sort.Ints(mns)
+		out[f] = mns
+	}
+	return out
+}
+
 // unique returns a deduplicated slice preserving order.
 func unique(ss []string) []string {
 	seen := make(map[string]bool)
@@ -0,0 +1,207 @@
+package collatinus
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// formIndexMagic and formIndexVersion head a serialized form index; the
+// version changes whenever the layout below does.
+const (
+	formIndexMagic   = "collatinus-formindex"
+	formIndexVersion = 3
+)
+
+// formIndexHeader opens a serialized form index. It is followed by Count
+// formIndexEntry values, in key order.
+type formIndexHeader struct {
+	Magic       string
+	Version     int
+	Fingerprint string
+	Count       int
+}
+
+// formIndexEntry is a serialized form index key with its analyses.
+type formIndexEntry struct {
+	Key      string
+	Analyses []formIndexAnalysis
+}
+
+// formIndexAnalysis is a serialized analysis. The morpho description is
+// not stored: it is recomputed from the lemma and morpho index.
+type formIndexAnalysis struct {
+	Lemma  string
+	Form   string
+	Morpho int
+	Stem   int
+	// Attested marks the analyses of irregular forms.
+	Attested bool
+}
+
+// formIndex maps a deramised, atone form to the analyses lemmatizeRaw
+// returns for it. It is built on demand by BuildFormIndex.
+type formIndex map[string][]indexedAnalysis
+
+// indexedAnalysis is an analysis together with the lemma it belongs to.
+type indexedAnalysis struct {
+	lemma    *Lemma
+	analysis Analysis
+}
+
+// buildFormIndex materializes the raw analyses of every generable form.
+// The keys come from the inflection tables of the whole lexicon; the
+// values are computed with lemmatizeRaw itself, in parallel, so that an
+// index hit returns exactly what the dynamic path would.
+func (l *Lemmatizer) buildFormIndex() formIndex {
+	keys := make(map[string]bool)
+	l.generateAllForms(func(g GeneratedForm) {
+		keys[g.Key] = true
+	})
+
+	work := make(chan string)
+	type entry struct {
+		key      string
+		analyses []indexedAnalysis
+	}
+	results := make(chan entry)
+
+	var wg sync.WaitGroup
+	for range runtime.GOMAXPROCS(0) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				var ias []indexedAnalysis
+				for lemma, analyses := range l.lemmatizeRaw(key) {
+					for _, a := range analyses {
+						a.Desinence = nil // Verbose only; see lemmatizeRaw
+						ias = append(ias, indexedAnalysis{lemma: lemma, analysis: a})
+					}
+				}
+				results <- entry{key, ias}
+			}
+		}()
+	}
+	go func() {
+		for key := range keys {
+			work <- key
+		}
+		close(work)
+		wg.Wait()
+		close(results)
+	}()
+
+	idx := make(formIndex, len(keys))
+	for e := range results {
+		idx[e.key] = e.analyses
+	}
+	return idx
+}
+
+// lookup returns a fresh result map for the deramised form and whether
+// the form is indexed. Callers may modify the returned map.
+func (idx formIndex) lookup(form string) (map[*Lemma][]Analysis, bool) {
+	entries, ok := idx[form]
+	if !ok {
+		return nil, false
+	}
+	result := make(map[*Lemma][]Analysis)
+	for _, e := range entries {
+		result[e.lemma] = append(result[e.lemma], e.analysis)
+	}
+	return result, true
+}
+
+// writeFormIndex writes idx to w as a gzip-compressed gob stream.
+func (l *Lemmatizer) writeFormIndex(w io.Writer, idx formIndex) error {
+	zw := gzip.NewWriter(w)
+	enc := gob.NewEncoder(zw)
+	err := enc.Encode(formIndexHeader{
+		Magic:       formIndexMagic,
+		Version:     formIndexVersion,
+		Fingerprint: l.fingerprint,
+		Count:       len(idx),
+	})
+	if err != nil {
+		return fmt.Errorf("write form index: %w", err)
+	}
+	keys := make([]string, 0, len(idx))
+	for k := range idx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e := formIndexEntry{Key: k, Analyses: make([]formIndexAnalysis, 0, len(idx[k]))}
+		for _, ia := range idx[k] {
+			e.Analyses = append(e.Analyses, formIndexAnalysis{
+				Lemma:    ia.lemma.Key,
+				Form:     ia.analysis.FormWithMarks,
+				Morpho:   ia.analysis.MorphoIndex,
+				Stem:     ia.analysis.StemLength,
+				Attested: ia.analysis.Attested,
+			})
+		}
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("write form index: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("write form index: %w", err)
+	}
+	return nil
+}
+
+// readFormIndex reads an index written by writeFormIndex from the same
+// data as l.
+func (l *Lemmatizer) readFormIndex(r io.Reader) (formIndex, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("read form index: %w", err)
+	}
+	defer zr.Close()
+	dec := gob.NewDecoder(zr)
+
+	var h formIndexHeader
+	if err := dec.Decode(&h); err != nil {
+		return nil, fmt.Errorf("read form index: %w", err)
+	}
+	if h.Magic != formIndexMagic {
+		return nil, fmt.Errorf("read form index: not a form index")
+	}
+	if h.Version != formIndexVersion {
+		return nil, fmt.Errorf("read form index: version %d, want %d", h.Version, formIndexVersion)
+	}
+	if h.Fingerprint != l.fingerprint {
+		return nil, fmt.Errorf("read form index: built from other data (fingerprint %.12s, loaded %.12s)", h.Fingerprint, l.fingerprint)
+	}
+
+	idx := make(formIndex, h.Count)
+	for range h.Count {
+		var e formIndexEntry
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("read form index: %w", err)
+		}
+		ias := make([]indexedAnalysis, 0, len(e.Analyses))
+		for _, a := range e.Analyses {
+			lemma := l.lemmas[a.Lemma]
+			if lemma == nil {
+				return nil, fmt.Errorf("read form index: unknown lemma %q", a.Lemma)
+			}
+			ias = append(ias, indexedAnalysis{lemma: lemma, analysis: Analysis{
+				FormWithMarks:     a.Form,
+				MorphoDescription: l.MorphoOf(lemma, a.Morpho),
+				Morphology:        l.morphologyOf(lemma, a.Morpho),
+				MorphoIndex:       a.Morpho,
+				StemLength:        a.Stem,
+				Attested:          a.Attested,
+			}})
+		}
+		idx[e.Key] = ias
+	}
+	return idx, nil
+}
@@ -1,5 +1,9 @@
 module github.com/cours-de-latin/collatinus
 
-go 1.25
+go 1.25.0
 
-require github.com/rs/cors v1.11.1
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/rs/cors v1.11.1
+	golang.org/x/text v0.39.0
+)
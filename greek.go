@@ -0,0 +1,80 @@
+package collatinus
+
+import (
+	"strings"
+	"unicode"
+)
+
+// greekSpellings pairs the spellings of Greek loanwords with their Latin
+// simplifications: "philosophia" is also written "filosofia", "thymbra"
+// "timbra" and "patrizo" "patrisso". Each pair is tried both ways.
+var greekSpellings = []struct{ greek, latin string }{
+	{"ph", "f"}, {"th", "t"}, {"ch", "c"}, {"rh", "r"}, {"y", "i"}, {"z", "ss"},
+}
+
+// maxGreekVariants bounds the spellings greekVariants tries for a form,
+// which double with each letter the table rewrites.
+const maxGreekVariants = 64
+
+// greekRewrite replaces form[start:end] with to.
+type greekRewrite struct {
+	start, end int
+	to         string
+}
+
+// greekVariants returns the spellings of form obtained by rewriting some
+// of its letters after greekSpellings, those with the fewest rewrites
+// first, at most maxGreekVariants. A rewritten capital stays capital:
+// "Filo" → "Philo".
+func greekVariants(form string) []string {
+	var sites []greekRewrite
+	for i := range form {
+		for _, p := range greekSpellings {
+			for _, s := range [][2]string{{p.greek, p.latin}, {p.latin, p.greek}} {
+				from, to := s[0], s[1]
+				if len(form)-i < len(from) || !strings.EqualFold(form[i:i+len(from)], from) {
+					continue
+				}
+				if unicode.IsUpper(rune(form[i])) {
+					to = upperFirst(to)
+				}
+				sites = append(sites, greekRewrite{i, i + len(from), to})
+			}
+		}
+	}
+
+	var out []string
+	seen := map[string]bool{form: true}
+	// pick chooses n more rewrites among sites[next:], after chosen.
+	var pick func(chosen []greekRewrite, next, n int)
+	pick = func(chosen []greekRewrite, next, n int) {
+		if len(out) >= maxGreekVariants {
+			return
+		}
+		if n == 0 {
+			var b strings.Builder
+			pos := 0
+			for _, r := range chosen {
+				b.WriteString(form[pos:r.start])
+				b.WriteString(r.to)
+				pos = r.end
+			}
+			b.WriteString(form[pos:])
+			if v := b.String(); !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+			return
+		}
+		for i := next; i < len(sites); i++ {
+			if len(chosen) > 0 && sites[i].start < chosen[len(chosen)-1].end {
+				continue
+			}
+			pick(append(chosen, sites[i]), i+1, n-1)
+		}
+	}
+	for n := 1; n <= len(sites) && len(out) < maxGreekVariants; n++ {
+		pick(nil, 0, n)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package collatinus
+
+import "strings"
+
+// labelTables maps a label locale to the translation of each word (or
+// two-word phrase) of the French morpho descriptions. French needs no
+// table: its labels are the descriptions themselves.
+var labelTables = map[string]map[string]string{
+	"en": {
+		"1ère": "1st", "2ème": "2nd", "3ème": "3rd",
+		"nominatif": "nominative", "vocatif": "vocative",
+		"accusatif": "accusative", "génitif": "genitive",
+		"datif": "dative", "ablatif": "ablative", "locatif": "locative",
+		"masculin": "masculine", "féminin": "feminine", "neutre": "neuter",
+		"singulier": "singular", "pluriel": "plural",
+		"indicatif": "indicative", "subjonctif": "subjunctive",
+		"impératif": "imperative", "infinitif": "infinitive",
+		"participe": "participle", "gérondif": "gerund",
+		"adjectif verbal": "gerundive", "supin": "supine", "en": "in",
+		"présent": "present", "imparfait": "imperfect", "futur": "future",
+		"parfait": "perfect", "PQP": "pluperfect",
+		"plus-que-parfait": "pluperfect", "futur antérieur": "future perfect",
+		"actif": "active", "passif": "passive",
+		"positif": "positive", "comparatif": "comparative",
+		"superlatif": "superlative", "inv.": "inv.",
+	},
+	"de": {
+		"1ère": "1.", "2ème": "2.", "3ème": "3.",
+		"nominatif": "Nominativ", "vocatif": "Vokativ",
+		"accusatif": "Akkusativ", "génitif": "Genitiv",
+		"datif": "Dativ", "ablatif": "Ablativ", "locatif": "Lokativ",
+		"masculin": "Maskulinum", "féminin": "Femininum", "neutre": "Neutrum",
+		"singulier": "Singular", "pluriel": "Plural",
+		"indicatif": "Indikativ", "subjonctif": "Konjunktiv",
+		"impératif": "Imperativ", "infinitif": "Infinitiv",
+		"participe": "Partizip", "gérondif": "Gerundium",
+		"adjectif verbal": "Gerundivum", "supin": "Supinum", "en": "auf",
+		"présent": "Präsens", "imparfait": "Imperfekt", "futur": "Futur",
+		"parfait": "Perfekt", "PQP": "Plusquamperfekt",
+		"plus-que-parfait": "Plusquamperfekt", "futur antérieur": "Futur II",
+		"actif": "Aktiv", "passif": "Passiv",
+		"positif": "Positiv", "comparatif": "Komparativ",
+		"superlatif": "Superlativ", "inv.": "undekl.",
+	},
+	"it": {
+		"1ère": "1ª", "2ème": "2ª", "3ème": "3ª",
+		"nominatif": "nominativo", "vocatif": "vocativo",
+		"accusatif": "accusativo", "génitif": "genitivo",
+		"datif": "dativo", "ablatif": "ablativo", "locatif": "locativo",
+		"masculin": "maschile", "féminin": "femminile", "neutre": "neutro",
+		"singulier": "singolare", "pluriel": "plurale",
+		"indicatif": "indicativo", "subjonctif": "congiuntivo",
+		"impératif": "imperativo", "infinitif": "infinito",
+		"participe": "participio", "gérondif": "gerundio",
+		"adjectif verbal": "gerundivo", "supin": "supino", "en": "in",
+		"présent": "presente", "imparfait": "imperfetto", "futur": "futuro",
+		"parfait": "perfetto", "PQP": "piuccheperfetto",
+		"plus-que-parfait": "piuccheperfetto", "futur antérieur": "futuro anteriore",
+		"actif": "attivo", "passif": "passivo",
+		"positif": "positivo", "comparatif": "comparativo",
+		"superlatif": "superlativo", "inv.": "inv.",
+	},
+}
+
+// translateLabel translates the French morpho description desc word by
+// word with table, trying two-word phrases first. Unknown words are kept.
+func translateLabel(desc string, table map[string]string) string {
+	var out []string
+	eachTerm(desc, table, func(word, t string, ok bool) {
+		if ok {
+			out = append(out, t)
+		} else {
+			out = append(out, word)
+		}
+	})
+	return strings.Join(out, " ")
+}
+
+// eachTerm calls fn for each term of the morpho description desc: a
+// two-word phrase found in table ("futur antérieur") or else a single
+// word, with its value in table and whether it was found.
+func eachTerm[V any](desc string, table map[string]V, fn func(term string, v V, ok bool)) {
+	words := strings.Fields(desc)
+	for i := 0; i < len(words); i++ {
+		if i+1 < len(words) {
+			phrase := words[i] + " " + words[i+1]
+			if v, ok := table[phrase]; ok {
+				fn(phrase, v, true)
+				i++
+				continue
+			}
+		}
+		v, ok := table[words[i]]
+		fn(words[i], v, ok)
+	}
+}
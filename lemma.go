@@ -1,10 +1,13 @@
 package collatinus
 
 import (
+	"maps"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Radical represents a stem used in inflection.
@@ -18,6 +21,9 @@ type Radical struct {
 	Num int
 	// Lemma is the lemma this radical belongs to.
 	Lemma *Lemma
+	// derived is true when the radical comes from the rules of the model
+	// rather than from lemmes.la.
+	derived bool
 }
 
 // Irreg represents an irregular inflected form.
@@ -34,6 +40,8 @@ type Irreg struct {
 	Lemma *Lemma
 	// Morphos lists the morpho indices this form covers.
 	Morphos []int
+	// src is where the form was read from in irregs.la.
+	src source
 }
 
 // Lemma represents a dictionary headword with all its inflectional data.
@@ -53,10 +61,20 @@ type Lemma struct {
 	IndMorph string
 	// POS is the part-of-speech.
 	POS PartOfSpeech
+	// posSource tells where POS comes from.
+	posSource POSSource
 	// HomonymNum is the homonym number (0 or 1 = primary, 2+ = secondary).
 	HomonymNum int
+	// register is the register parsed from IndMorph.
+	register Register
+	// Deponent is true for verbs passive in form but active in meaning
+	// (imitor, loquor, patior).
+	Deponent bool
 	// renvoi is a cross-reference key (when IndMorph contains "cf. xxx").
 	renvoi string
+	// refsOut and refsIn are the lemmas renvoi resolves to and the lemmas
+	// whose renvoi resolves to this one, by key.
+	refsOut, refsIn []*Lemma
 
 	// altGrqs holds additional canonical forms with quantity marks (comma-separated
 	// alternatives after the first form in the lemmes.la Grq field).
@@ -72,6 +90,10 @@ type Lemma struct {
 	NbOcc int
 	// translations maps language code → translation string.
 	translations map[string]string
+	// numValue is the value of a cardinal or ordinal numeral, 0 otherwise.
+	numValue int
+	// src is where the lemma was read from in lemmes.la.
+	src source
 }
 
 // cfRe matches "cf. <word>" at the end of indMorph.
@@ -124,7 +146,7 @@ func newLemma(line string) *Lemma {
 		}
 		radNum := i - 1 // field 2 → radical 1, field 3 → radical 2
 		for _, radStr := range strings.Split(parts[i], ",") {
-			if radStr == "" {
+			if radStr == "" || l.hasRadical(radNum, Atone(radStr)) {
 				continue
 			}
 			rad := &Radical{
@@ -138,13 +160,23 @@ func newLemma(line string) *Lemma {
 	}
 
 	l.IndMorph = parts[4]
-	l.POS = detectPOS(l.IndMorph)
+	if l.POS = detectPOS(l.IndMorph); l.POS != POSUnknown {
+		l.posSource = POSFromHeuristic
+	}
+	l.register = detectRegister(l.IndMorph)
 
 	// Field 6: NbOcc (occurrence count)
 	if len(parts) >= 6 && parts[5] != "" {
 		l.NbOcc, _ = strconv.Atoi(parts[5])
 	}
 
+	// Field 7: the part-of-speech code, overriding the guess from indMorph
+	if len(parts) >= 7 {
+		if pos, ok := parsePOSCode(parts[6]); ok {
+			l.POS, l.posSource = pos, POSFromData
+		}
+	}
+
 	// Cross-reference
 	if m := cfRe.FindStringSubmatch(l.IndMorph); m != nil {
 		l.renvoi = m[1]
@@ -170,10 +202,21 @@ func oteNh(g string) (string, int) {
 	return g, 0
 }
 
+// parsePOSCode reads a part-of-speech code of posCodes.
+func parsePOSCode(s string) (PartOfSpeech, bool) {
+	if utf8.RuneCountInString(s) != 1 {
+		return POSUnknown, false
+	}
+	pos := PartOfSpeech([]rune(s)[0])
+	return pos, slices.Contains(posCodes, pos)
+}
+
 // detectPOS infers part of speech from the indMorph string.
 // Mirrors the POS detection in Lemme::Lemme.
 func detectPOS(indMorph string) PartOfSpeech {
 	switch {
+	case strings.Contains(indMorph, "num."):
+		return POSNumeral
 	case strings.Contains(indMorph, "adj."):
 		return POSAdjective
 	case strings.Contains(indMorph, "conj"):
@@ -182,8 +225,6 @@ func detectPOS(indMorph string) PartOfSpeech {
 		return POSExclamation
 	case strings.Contains(indMorph, "interj"):
 		return POSInterjection
-	case strings.Contains(indMorph, "num."):
-		return POSNumeral
 	case strings.Contains(indMorph, "pron."):
 		return POSPronoun
 	case strings.Contains(indMorph, "prép"):
@@ -197,6 +238,42 @@ func detectPOS(indMorph string) PartOfSpeech {
 	}
 }
 
+// isDeponent tells whether a verb lemma is deponent, from its model
+// ancestry or from its morphological information.
+func isDeponent(l *Lemma) bool {
+	if l.POS != POSVerb {
+		return false
+	}
+	return (l.model != nil && l.model.EstUn("imitor")) || strings.Contains(l.IndMorph, "dép.")
+}
+
+// detectRegister reads the register markers of the indMorph string.
+func detectRegister(indMorph string) Register {
+	switch {
+	case strings.Contains(indMorph, "arch."):
+		return RegisterArchaic
+	case strings.Contains(indMorph, "poét."):
+		return RegisterPoetic
+	case strings.Contains(indMorph, "tard.") || strings.Contains(indMorph, "bas lat."):
+		return RegisterLate
+	case strings.Contains(indMorph, "médiév."):
+		return RegisterMedieval
+	default:
+		return RegisterClassical
+	}
+}
+
+// POSSource tells where POS comes from: the lexicon line itself, the
+// guess from IndMorph, or the inflection model.
+func (l *Lemma) POSSource() POSSource {
+	return l.posSource
+}
+
+// Register returns the register of the lemma (classical when unmarked).
+func (l *Lemma) Register() Register {
+	return l.register
+}
+
 // Model returns the resolved Model for this lemma.
 func (l *Lemma) Model() *Model {
 	return l.model
@@ -210,6 +287,40 @@ func (l *Lemma) Translation(lang string) string {
 	return l.translations["fr"]
 }
 
+// TranslationLanguages returns the sorted codes of the languages the
+// lemma has a translation in.
+func (l *Lemma) TranslationLanguages() []string {
+	return slices.Sorted(maps.Keys(l.translations))
+}
+
+// hasRadical tells whether the lemma already has a radical numbered num
+// spelled gr (without quantity marks).
+func (l *Lemma) hasRadical(num int, gr string) bool {
+	for _, r := range l.radicals[num] {
+		if r.Gr == gr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReferencesOut returns the lemmas the entry's "cf." cross-reference
+// points to, empty when it has none or it names no known lemma.
+func (l *Lemma) ReferencesOut() []*Lemma {
+	return l.refsOut
+}
+
+// NumericValue returns the value of a cardinal or ordinal numeral lemma
+// (3 for tres and for tertius), and false for other lemmas.
+func (l *Lemma) NumericValue() (int, bool) {
+	return l.numValue, l.numValue != 0
+}
+
+// Source returns the data file and 1-based line the lemma was read from.
+func (l *Lemma) Source() (file string, line int) {
+	return l.src.file, l.src.line
+}
+
 // AddTranslation adds a translation for the given language code.
 func (l *Lemma) AddTranslation(lang, text string) {
 	l.translations[lang] = text
@@ -246,6 +357,17 @@ func (l *Lemma) irregAt(i int) (string, bool) {
 	return "", false
 }
 
+// Source returns the data file and 1-based line the form was read from.
+func (ir *Irreg) Source() (file string, line int) {
+	return ir.src.file, ir.src.line
+}
+
+// RadicalNumbers returns the sorted numbers of the radicals of the
+// lemma, those of the lexicon line and those its model derives.
+func (l *Lemma) RadicalNumbers() []int {
+	return slices.Sorted(maps.Keys(l.radicals))
+}
+
 // RadicalsAt returns all radicals for radical number r.
 func (l *Lemma) RadicalsAt(r int) []*Radical {
 	return l.radicals[r]
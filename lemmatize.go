@@ -1,11 +1,72 @@
 package collatinus
 
 import (
+	"cmp"
+	"iter"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 )
 
+// LemmatizeOptions tunes the optional fallbacks and filters applied by
+// LemmatizeWord and LemmatizeText. The zero value reproduces the
+// behaviour of Collatinus.
+type LemmatizeOptions struct {
+	// Prefixes enables the compound-verb fallback: when a form cannot be
+	// lemmatized at all, a known prepositional prefix is stripped and the
+	// remainder is analyzed as a verb.
+	Prefixes bool
+	// MinFrequency drops the lemmas whose occurrence count (NbOcc) is
+	// below this threshold, unless no lemma would remain.
+	MinFrequency int
+	// ExcludeRegisters drops the lemmas of the listed registers (e.g.
+	// RegisterArchaic for classicists), unless no lemma would remain.
+	ExcludeRegisters []Register
+	// PreferRegisters ranks first the lemmas of the listed registers
+	// (e.g. RegisterMedieval for medieval texts), in RankLemmas, Scores
+	// and LemmatizeWordRanked. No lemma is dropped.
+	PreferRegisters []Register
+	// KeepSpelling makes FormWithMarks follow the u/v and i/j spelling of
+	// the input ("uinum" → "uīnŭm", "Iulius" → "Iūlĭŭs"); lookup is
+	// unaffected.
+	KeepSpelling bool
+	// PreferLongStems ranks first the analyses with the longest
+	// StemLength, within each lemma and in RankLemmas, rather than
+	// trusting spurious cuts of short stems. No analysis is dropped.
+	PreferLongStems bool
+	// AllowedMorphos, when not empty, keeps only the analyses of these
+	// morpho indices (say, the subjunctives) and the lemmas left with
+	// some. Unlike the filters above, it may leave no lemma at all.
+	AllowedMorphos []int
+	// MaxLemmas and MaxAnalysesPerLemma, when positive, bound the
+	// result: only the first MaxLemmas lemmas in RankLemmas order are
+	// kept, each with its MaxAnalysesPerLemma best analyses by
+	// AnalysisScore.Score. They protect clients from the dozens of
+	// analyses of some short or garbage inputs.
+	MaxLemmas           int
+	MaxAnalysesPerLemma int
+	// GreekSpellings retries a form that cannot be lemmatized with the
+	// other spellings of Greek loanwords, ph/f, th/t, ch/c, rh/r, y/i and
+	// z/ss, so that "filosofia" finds philosophia.
+	GreekSpellings bool
+	// Verbose sets Analysis.Desinence, for debugging the data. It
+	// bypasses the form index, which does not keep the desinences.
+	Verbose bool
+	// Inscriptions makes LemmatizeText read a text written mostly in
+	// capitals, as inscriptions are, word by word in both lower and title
+	// case, wherever the word stands, and with V for u or v: "IMPERATOR
+	// CAESAR AVGVSTVS" is imperator, Caesar and Augustus. Words in lower
+	// case are read as usual.
+	Inscriptions bool
+	// JoinHyphenated makes LemmatizeText rejoin the words broken across
+	// lines with a hyphen; see TokenizeOptions.
+	JoinHyphenated bool
+}
+
 // reWord matches a single Latin/Unicode word token.
 var reWord = regexp.MustCompile(`[a-zA-ZÀ-ÿ\x{0100}-\x{024F}\x{0300}-\x{036F}]+`)
 
@@ -13,6 +74,44 @@ var reWord = regexp.MustCompile(`[a-zA-ZÀ-ÿ\x{0100}-\x{024F}\x{0300}-\x{036F}]
 // Mirrors the suffixes map in LemCore constructor: ne, que, ue, ve, st.
 var enclitics = []string{"ne", "que", "ue", "ve", "st"}
 
+// cumPronouns lists the keys of the personal and reflexive pronouns whose
+// ablative takes the preposition cum as an enclitic: mecum, tecum, secum,
+// nobiscum, uobiscum.
+var cumPronouns = []string{"ego", "tu", "se", "nos", "uos"}
+
+// verbPrefixes lists the prepositional prefixes tried by the compound-verb
+// fallback, as (atone, quantity-marked) pairs, longest first.
+var verbPrefixes = []struct{ gr, grq string }{
+	{"praeter", "prāetĕr"},
+	{"circum", "cīrcŭm"},
+	{"subter", "sŭbtĕr"},
+	{"super", "sŭpĕr"},
+	{"inter", "īntĕr"},
+	{"trans", "trāns"},
+	{"ante", "āntĕ"},
+	{"post", "pōst"},
+	{"prae", "prāe"},
+	{"per", "pĕr"},
+	{"pro", "prō"},
+	{"sub", "sŭb"},
+	{"dis", "dĭs"},
+	{"con", "cŏn"},
+	{"com", "cŏm"},
+	{"abs", "ābs"},
+	{"ab", "ăb"},
+	{"ad", "ăd"},
+	{"de", "dē"},
+	{"ex", "ĕx"},
+	{"in", "ĭn"},
+	{"ob", "ŏb"},
+	{"re", "rĕ"},
+}
+
+// minPrefixedRemainder is the shortest remainder (in runes) the
+// compound-verb fallback accepts after stripping a prefix, so that short
+// words are not torn apart.
+const minPrefixedRemainder = 3
+
 // assim applies the assimilation table to a.
 // Mirrors Lemmat::assim.
 func (l *Lemmatizer) assim(a string) string {
@@ -52,18 +151,48 @@ func (l *Lemmatizer) decontracte(d string) string {
 // 2. radical+desinence combinations
 // Mirrors Lemmat::lemmatise.
 func (l *Lemmatizer) lemmatizeRaw(form string) map[*Lemma][]Analysis {
-	// Compute vowel counts from original form (before deramise)
+	// The form index holds exactly what this function computes, but only
+	// for forms the vowel-count check of eachRaw cannot filter, and
+	// without the desinences of Verbose.
+	if l.index != nil && !l.opts.Verbose && Deramise(form) == form {
+		if result, ok := l.index.lookup(form); ok {
+			return result
+		}
+	}
+
+	result := make(map[*Lemma][]Analysis)
+	l.eachRaw(form, func(lemma *Lemma, an Analysis) bool {
+		result[lemma] = append(result[lemma], an)
+		return true
+	})
+	return result
+}
+
+// hasRaw reports whether lemmatizeRaw(form) would find any analysis,
+// without building the result.
+func (l *Lemmatizer) hasRaw(form string) bool {
+	if l.index != nil && Deramise(form) == form {
+		if entries, ok := l.index[form]; ok {
+			return len(entries) > 0
+		}
+	}
+	return !l.eachRaw(form, func(*Lemma, Analysis) bool { return false })
+}
+
+// eachRaw calls fn for each analysis lemmatizeRaw finds for form, in
+// order, until fn returns false. It returns false if fn stopped it.
+func (l *Lemmatizer) eachRaw(form string, fn func(*Lemma, Analysis) bool) bool {
+	// The spelling the input commits to, which deramise loses, must be
+	// that of each analysis: as many v as the radical and desinence
+	// spell, and as many æ and œ as they hold ae and oe diphthongs, so
+	// that ædes is not read with the hiatus of aër. Inputs spelling none
+	// of them are not checked.
 	lower := strings.ToLower(form)
 	cntV := strings.Count(lower, "v")
 	cntAe := strings.Count(lower, "\u00e6") // æ
 	cntOe := strings.Count(lower, "\u0153") // œ
-	// subtract trailing æ (matches C++ behaviour)
-	if strings.HasSuffix(lower, "\u00e6") {
-		cntAe--
-	}
 
 	form = Deramise(form)
-	result := make(map[*Lemma][]Analysis)
 
 	// 1. Check irregular forms
 	if irregs, ok := l.irregs[form]; ok {
@@ -71,10 +200,15 @@ func (l *Lemmatizer) lemmatizeRaw(form string) map[*Lemma][]Analysis {
 			for _, mn := range irr.Morphos {
 				an := Analysis{
 					FormWithMarks:     irr.Grq,
-					MorphoDescription: l.Morpho(mn),
+					MorphoDescription: l.MorphoOf(irr.Lemma, mn),
+					Morphology:        l.morphologyOf(irr.Lemma, mn),
 					MorphoIndex:       mn,
+					StemLength:        len([]rune(form)),
+					Attested:          true,
+				}
+				if !fn(irr.Lemma, an) {
+					return false
 				}
-				result[irr.Lemma] = append(result[irr.Lemma], an)
 			}
 		}
 	}
@@ -106,17 +240,20 @@ func (l *Lemmatizer) lemmatizeRaw(form string) map[*Lemma][]Analysis {
 
 		if needDoubleI {
 			nf := r + "i" + d
-			nm := l.lemmatizeRaw(nf)
 			// Remove the extra 'i' we inserted from each returned grq
 			rLen := len([]rune(r))
-			for nl, lsl := range nm {
-				for k := range lsl {
-					grq := []rune(lsl[k].FormWithMarks)
-					if rLen > 0 && rLen-1 < len(grq) {
-						lsl[k].FormWithMarks = string(grq[:rLen-1]) + string(grq[rLen:])
-					}
+			more := l.eachRaw(nf, func(nl *Lemma, an Analysis) bool {
+				grq := []rune(an.FormWithMarks)
+				if rLen > 0 && rLen-1 < len(grq) {
+					an.FormWithMarks = string(grq[:rLen-1]) + string(grq[rLen:])
+				}
+				if an.StemLength > rLen {
+					an.StemLength--
 				}
-				result[nl] = append(result[nl], lsl...)
+				return fn(nl, an)
+			})
+			if !more {
+				return false
 			}
 		}
 
@@ -141,34 +278,412 @@ func (l *Lemmatizer) lemmatizeRaw(form string) map[*Lemma][]Analysis {
 					continue
 				}
 
-				// Vowel-count consistency check (mirrors C++ lemmatise())
-				radGrqLower := strings.ToLower(rad.Grq)
-				desGrqLower := strings.ToLower(de.Grq)
-				cOK := (cntV == 0) || (cntV == strings.Count(radGrqLower, "v")+strings.Count(desGrqLower, "v"))
-				cOK = cOK && ((cntOe == 0) || (cntOe == strings.Count(radGrqLower, "\u014de")))                                         // ōe
-				cOK = cOK && ((cntAe == 0) || (cntAe == strings.Count(radGrqLower, "\u0101e")+strings.Count(radGrqLower, "pr\u0103e"))) // āe + prăe
+				// Vowel-count consistency check (after C++ lemmatise(),
+				// which only counted the diphthongs of the radical and
+				// so rejected hæc and quædam)
+				grq := strings.ToLower(rad.Grq + de.Grq)
+				cOK := (cntV == 0) || (cntV == strings.Count(grq, "v"))
+				cOK = cOK && ((cntOe == 0) || (cntOe == diphthongs(grq, 'o')))
+				cOK = cOK && ((cntAe == 0) || (cntAe == diphthongs(grq, 'a')))
 				if !cOK {
 					continue
 				}
 
 				an := Analysis{
 					FormWithMarks:     rad.Grq + de.Grq,
-					MorphoDescription: l.Morpho(de.MorphoNum),
+					MorphoDescription: l.MorphoOf(lemma, de.MorphoNum),
+					Morphology:        l.morphologyOf(lemma, de.MorphoNum),
 					MorphoIndex:       de.MorphoNum,
+					StemLength:        i,
+				}
+				if l.opts.Verbose {
+					an.Desinence = de
+				}
+				if !fn(lemma, an) {
+					return false
 				}
-				result[lemma] = append(result[lemma], an)
 			}
 		}
 	}
 
-	return result
+	return true
+}
+
+// diphthongs counts the diphthongs v+e of the lower-case marked form grq,
+// where v is a or o with any quantity mark and e bears none: āe and prăe
+// count, ăē of aër does not.
+func diphthongs(grq string, v rune) int {
+	rs := []rune(norm.NFD.String(grq))
+	n := 0
+	for i, r := range rs {
+		if r != v {
+			continue
+		}
+		j := i + 1
+		for j < len(rs) && unicode.Is(unicode.Mn, rs[j]) {
+			j++
+		}
+		if j < len(rs) && rs[j] == 'e' && (j+1 == len(rs) || !unicode.Is(unicode.Mn, rs[j+1])) {
+			n++
+		}
+	}
+	return n
+}
+
+// segmentations returns every stem/ending cut of form for which both a
+// radical and a desinence are known, shortest stem first.
+func (l *Lemmatizer) segmentations(form string) []Segmentation {
+	form = Deramise(form)
+	var segs []Segmentation
+	runes := []rune(form)
+	for i := 0; i <= len(runes); i++ {
+		r := string(runes[:i])
+		d := string(runes[i:])
+		rads, hasRad := l.radicals[r]
+		if !hasRad {
+			continue
+		}
+		des, hasDes := l.desinences[d]
+		if !hasDes {
+			continue
+		}
+		segs = append(segs, Segmentation{
+			Stem:       r,
+			Ending:     d,
+			Radicals:   rads,
+			Desinences: des,
+		})
+	}
+	return segs
+}
+
+// stemOf implements StemOf. Only the segmentations whose radical and
+// desinence agree in model and radical number count; among them, the
+// most frequent lemma wins, then its longest stem.
+func (l *Lemmatizer) stemOf(form string) (stem, ending string, ok bool) {
+	plain := Atone(form)
+	segs := l.segmentations(plain)
+	if len(segs) == 0 && plain != strings.ToLower(plain) {
+		segs = l.segmentations(strings.ToLower(plain))
+	}
+	var best *Lemma
+	bestLen := 0
+	for _, seg := range segs {
+		n := len([]rune(seg.Stem))
+		for _, rad := range seg.Radicals {
+			lemma := rad.Lemma
+			if !slices.ContainsFunc(seg.Desinences, func(de *Desinence) bool {
+				return de.Model == lemma.model && de.RadNum == rad.Num &&
+					!lemma.isExclusiveIrreg(de.MorphoNum) &&
+					de.MorphoNum >= 1 && de.MorphoNum < len(l.morphos)
+			}) {
+				continue
+			}
+			switch {
+			case best == nil,
+				lemma == best && n > bestLen,
+				lemma != best && lemma.NbOcc > best.NbOcc,
+				lemma != best && lemma.NbOcc == best.NbOcc && lemma.Key < best.Key:
+				best, bestLen = lemma, n
+			}
+		}
+	}
+	if best == nil {
+		return "", "", false
+	}
+	// Cut the input where its plain spelling reaches bestLen runes, marks
+	// staying on their vowel; a ligature straddling the cut (æ of Musæ)
+	// goes to the ending.
+	in := []rune(form)
+	i, n := 0, 0
+	for i < len(in) {
+		w := len([]rune(Deramise(Atone(string(in[i])))))
+		if n+w > bestLen {
+			break
+		}
+		n += w
+		i++
+	}
+	return string(in[:i]), string(in[i:]), true
 }
 
 // lemmatizeM implements the full lemmatization with all fallbacks.
 // Mirrors LemCore::lemmatiseM using recursive etapes logic.
 // etape=0 is the entry point; higher etapes are more basic.
 func (l *Lemmatizer) lemmatizeM(form string, sentenceStart bool) map[*Lemma][]Analysis {
-	return l.lemmatizeMEtape(form, sentenceStart, 0)
+	return l.finishAnalyses(form, l.lemmatizeMEtape(form, sentenceStart, 0))
+}
+
+// finishAnalyses applies to the analyses mm of form the prefix fallback,
+// the filters and the bounds of the options.
+func (l *Lemmatizer) finishAnalyses(form string, mm map[*Lemma][]Analysis) map[*Lemma][]Analysis {
+	if len(mm) == 0 && l.opts.Prefixes {
+		mm = l.lemmatizePrefixed(form)
+	}
+	// The same analysis may come from several radicals or paths.
+	for lemma, analyses := range mm {
+		mm[lemma] = DedupeAnalyses(analyses)
+	}
+	mm = l.filterResults(mm)
+	if l.opts.PreferLongStems {
+		for _, analyses := range mm {
+			sort.SliceStable(analyses, func(i, j int) bool {
+				return analyses[i].StemLength > analyses[j].StemLength
+			})
+		}
+	}
+	// Bound the output last, keeping what ranks first.
+	if n := l.opts.MaxLemmas; n > 0 && len(mm) > n {
+		for _, lemma := range l.rankLemmas(mm)[n:] {
+			delete(mm, lemma)
+		}
+	}
+	if n := l.opts.MaxAnalysesPerLemma; n > 0 {
+		for lemma, analyses := range mm {
+			if len(analyses) > n {
+				sort.SliceStable(analyses, func(i, j int) bool {
+					return analysisScore(analyses[i]).Score() > analysisScore(analyses[j]).Score()
+				})
+				mm[lemma] = analyses[:n]
+			}
+		}
+	}
+	if l.opts.KeepSpelling {
+		for _, analyses := range mm {
+			for i := range analyses {
+				analyses[i].FormWithMarks = keepSpelling(form, analyses[i].FormWithMarks)
+			}
+		}
+	}
+	return mm
+}
+
+// isValidForm implements IsValidForm. The filters of lemmatizeM never
+// empty a result, so they are skipped, but for AllowedMorphos.
+func (l *Lemmatizer) isValidForm(form string) bool {
+	if form == "" {
+		return false
+	}
+	if len(l.opts.AllowedMorphos) > 0 {
+		return len(l.lemmatizeM(form, false)) > 0
+	}
+	if l.hasRaw(form) {
+		return true
+	}
+	if len(l.lemmatizeMEtape(form, false, 0)) > 0 {
+		return true
+	}
+	return l.opts.Prefixes && len(l.lemmatizePrefixed(form)) > 0
+}
+
+// keepSpelling rewrites the u/v and i/j letters of marked after the
+// letters of form at the same positions. The alignment stops at the first
+// letter that differs otherwise (an enclitic, a ligature).
+func keepSpelling(form, marked string) string {
+	in := []rune(form)
+	var b strings.Builder
+	i := 0
+	for _, m := range marked {
+		for i < len(in) && unicode.Is(unicode.Mn, in[i]) {
+			i++
+		}
+		if unicode.Is(unicode.Mn, m) || i >= len(in) {
+			b.WriteRune(m)
+			continue
+		}
+		c := in[i]
+		i++
+		if !strings.EqualFold(Deramise(Atone(string(m))), Deramise(string(c))) {
+			i = len(in)
+			b.WriteRune(m)
+			continue
+		}
+		// Bare u, v, i and j take the input letter; marked vowels stay.
+		switch unicode.ToLower(m) {
+		case 'u', 'v', 'i', 'j':
+			if unicode.IsUpper(m) {
+				m = unicode.ToUpper(c)
+			} else {
+				m = unicode.ToLower(c)
+			}
+		}
+		b.WriteRune(m)
+	}
+	return b.String()
+}
+
+// filterResults applies the optional post-filters of the lemmatization
+// options to mm.
+func (l *Lemmatizer) filterResults(mm map[*Lemma][]Analysis) map[*Lemma][]Analysis {
+	if l.opts.MinFrequency > 0 {
+		frequent := make(map[*Lemma][]Analysis)
+		for lemma, analyses := range mm {
+			if lemma.NbOcc >= l.opts.MinFrequency {
+				frequent[lemma] = analyses
+			}
+		}
+		if len(frequent) > 0 {
+			mm = frequent
+		}
+	}
+	if len(l.opts.ExcludeRegisters) > 0 {
+		kept := make(map[*Lemma][]Analysis)
+		for lemma, analyses := range mm {
+			if !slices.Contains(l.opts.ExcludeRegisters, lemma.register) {
+				kept[lemma] = analyses
+			}
+		}
+		if len(kept) > 0 {
+			mm = kept
+		}
+	}
+	if len(l.opts.AllowedMorphos) > 0 {
+		allowed := make(map[*Lemma][]Analysis)
+		for lemma, analyses := range mm {
+			var as []Analysis
+			for _, a := range analyses {
+				if slices.Contains(l.opts.AllowedMorphos, a.MorphoIndex) {
+					as = append(as, a)
+				}
+			}
+			if len(as) > 0 {
+				allowed[lemma] = as
+			}
+		}
+		mm = allowed
+	}
+	return mm
+}
+
+// rankLemmas implements RankLemmas.
+func (l *Lemmatizer) rankLemmas(mm map[*Lemma][]Analysis) []*Lemma {
+	stem := make(map[*Lemma]int, len(mm))
+	lemmas := make([]*Lemma, 0, len(mm))
+	for lemma, analyses := range mm {
+		for _, a := range analyses {
+			stem[lemma] = max(stem[lemma], a.StemLength)
+		}
+		lemmas = append(lemmas, lemma)
+	}
+	sort.Slice(lemmas, func(i, j int) bool {
+		a, b := lemmas[i], lemmas[j]
+		if pa, pb := l.prefersRegister(a), l.prefersRegister(b); pa != pb {
+			return pa
+		}
+		if l.opts.PreferLongStems && stem[a] != stem[b] {
+			return stem[a] > stem[b]
+		}
+		if a.NbOcc != b.NbOcc {
+			return a.NbOcc > b.NbOcc
+		}
+		return a.Key < b.Key
+	})
+	return lemmas
+}
+
+// rankPenalty is the factor of the score of a lemma reached by a
+// fallback; see RankedAnalysis.
+const rankPenalty = 0.5
+
+// lemmatizeWordRanked implements LemmatizeWordRanked.
+func (l *Lemmatizer) lemmatizeWordRanked(form string, sentenceStart bool) []RankedAnalysis {
+	mm := l.lemmatizeM(form, sentenceStart)
+	lower := form != "" && unicode.IsLower([]rune(form)[0])
+	ranked := make([]RankedAnalysis, 0, len(mm))
+	for lemma, analyses := range mm {
+		score := float64(lemma.NbOcc)
+		if !slices.ContainsFunc(analyses, func(a Analysis) bool { return a.Derivation == 0 }) {
+			score *= rankPenalty
+		}
+		if lower && lemma.Gr != "" && unicode.IsUpper([]rune(lemma.Gr)[0]) {
+			score *= rankPenalty
+		}
+		ranked = append(ranked, RankedAnalysis{Lemma: lemma, Analyses: analyses, Score: score})
+	}
+	slices.SortFunc(ranked, func(a, b RankedAnalysis) int {
+		if pa, pb := l.prefersRegister(a.Lemma), l.prefersRegister(b.Lemma); pa != pb {
+			if pa {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Lemma.Key, b.Lemma.Key)
+	})
+	return ranked
+}
+
+// lemmatizePrefixed is the compound-verb fallback: it strips the first
+// known prefix of form whose remainder lemmatizes as a verb, and returns
+// those verb analyses with the prefix recorded and prepended to the form.
+func (l *Lemmatizer) lemmatizePrefixed(form string) map[*Lemma][]Analysis {
+	lower := strings.ToLower(Deramise(form))
+	for _, p := range verbPrefixes {
+		if !strings.HasPrefix(lower, p.gr) {
+			continue
+		}
+		rest := lower[len(p.gr):]
+		if len([]rune(rest)) < minPrefixedRemainder {
+			continue
+		}
+		var mm map[*Lemma][]Analysis
+		for nl, lsl := range l.lemmatizeMEtape(rest, false, 1) {
+			if nl.POS != POSVerb {
+				continue
+			}
+			for k := range lsl {
+				lsl[k].Prefix = p.gr
+				lsl[k].FormWithMarks = p.grq + lsl[k].FormWithMarks
+				lsl[k].StemLength += len([]rune(p.gr))
+				lsl[k].Derivation |= SourcePrefix
+			}
+			if mm == nil {
+				mm = make(map[*Lemma][]Analysis)
+			}
+			mm[nl] = append(mm[nl], lsl...)
+		}
+		if len(mm) > 0 {
+			return mm
+		}
+	}
+	return nil
+}
+
+// lemmatizeCum analyses form as a personal or reflexive pronoun ablative
+// followed by the enclitic preposition cum ("mecum" → mē + cŭm), and
+// returns the ablative analyses of the pronoun, with Enclitic "cum",
+// together with the preposition, or nil.
+func (l *Lemmatizer) lemmatizeCum(form string) map[*Lemma][]Analysis {
+	lower := strings.ToLower(form)
+	if !strings.HasSuffix(lower, "cum") {
+		return nil
+	}
+	var mm map[*Lemma][]Analysis
+	for lemma, analyses := range l.lemmatizeRaw(strings.TrimSuffix(lower, "cum")) {
+		if !slices.Contains(cumPronouns, lemma.Key) {
+			continue
+		}
+		for _, a := range analyses {
+			if strings.Contains(a.MorphoDescription, "ablatif") {
+				if mm == nil {
+					mm = make(map[*Lemma][]Analysis)
+				}
+				a.Enclitic = "cum"
+				mm[lemma] = append(mm[lemma], a)
+			}
+		}
+	}
+	if mm == nil {
+		return nil
+	}
+	for lemma, analyses := range l.lemmatizeRaw("cum") {
+		if lemma.POS == POSPreposition {
+			mm[lemma] = analyses
+		}
+	}
+	return mm
 }
 
 // lemmatizeMEtape implements the etapes-based lemmatization.
@@ -183,12 +698,12 @@ func (l *Lemmatizer) lemmatizeMEtape(form string, sentenceStart bool, etape int)
 		mm := l.lemmatizeRaw(form)
 		if sentenceStart && len(form) > 0 && unicode.IsUpper([]rune(form)[0]) {
 			nf := strings.ToLower(form)
-			for nl, lsl := range l.lemmatizeMEtape(nf, false, 4) {
-				if mm == nil {
-					mm = make(map[*Lemma][]Analysis)
-				}
-				mm[nl] = append(mm[nl], lsl...)
-			}
+			mm = mergeAnalyses(mm, l.lemmatizeMEtape(nf, false, 4))
+		}
+		// Words written in capitals ("ÆNEAS", "OEdipus" for Œdipus) may
+		// still be proper nouns: try the title-cased form as well.
+		if hasInnerUpper(form) {
+			mm = mergeAnalyses(mm, l.lemmatizeMEtape(titleCase(form), false, 4))
 		}
 		return mm
 	}
@@ -201,38 +716,26 @@ func (l *Lemmatizer) lemmatizeMEtape(form string, sentenceStart bool, etape int)
 		// Contraction expansion (always tried, merged with base results)
 		fd := l.decontracte(form)
 		if fd != form {
-			for nl, lsl := range l.lemmatizeMEtape(fd, sentenceStart, 4) {
-				if mm == nil {
-					mm = make(map[*Lemma][]Analysis)
-				}
-				mm[nl] = append(mm[nl], lsl...)
-			}
+			mm = mergeAnalyses(mm, derive(l.lemmatizeMEtape(fd, sentenceStart, 4), SourceContraction))
 		}
 
 	case 2:
 		// Assimilation and deassimilation (always tried)
 		fa := l.assim(form)
 		if fa != form {
-			for nl, lsl := range l.lemmatizeMEtape(fa, sentenceStart, 3) {
-				if mm == nil {
-					mm = make(map[*Lemma][]Analysis)
-				}
-				mm[nl] = append(mm[nl], lsl...)
-			}
+			mm = mergeAnalyses(mm, derive(l.lemmatizeMEtape(fa, sentenceStart, 3), SourceAssimilation))
 			return mm
 		}
 		fd := l.desassim(form)
 		if fd != form {
-			for nl, lsl := range l.lemmatizeMEtape(fd, sentenceStart, 3) {
-				if mm == nil {
-					mm = make(map[*Lemma][]Analysis)
-				}
-				mm[nl] = append(mm[nl], lsl...)
-			}
+			mm = mergeAnalyses(mm, derive(l.lemmatizeMEtape(fd, sentenceStart, 3), SourceAssimilation))
 			return mm
 		}
 
 	case 1:
+		// Enclitic cum after a pronoun ablative (always tried: the
+		// lexicon lists some of these forms as contracted lemmas)
+		mm = mergeAnalyses(mm, derive(l.lemmatizeCum(form), SourceEnclitic))
 		// Suffixes/enclitics (only when no results yet)
 		if len(mm) == 0 {
 			for _, suf := range enclitics {
@@ -247,42 +750,129 @@ func (l *Lemmatizer) lemmatizeMEtape(form string, sentenceStart bool, etape int)
 					} else {
 						mm = l.lemmatizeMEtape(sf, sentenceStart, 1)
 					}
+					derive(mm, SourceEnclitic)
+					for _, analyses := range mm {
+						for i := range analyses {
+							analyses[i].Enclitic = suf
+						}
+					}
 				}
 			}
 		}
 
 	case 0:
+		// Spellings of Greek loanwords (optional, only when no results)
+		if len(mm) == 0 && l.opts.GreekSpellings {
+			for _, v := range greekVariants(form) {
+				mm = mergeAnalyses(mm, derive(l.lemmatizeMEtape(v, sentenceStart, 1), SourceGreekSpelling))
+			}
+			if len(mm) > 0 {
+				return mm
+			}
+		}
 		// Capitalize first letter for proper-noun fallback (only when no results)
 		if len(mm) == 0 && len(form) > 0 && unicode.IsLower([]rune(form)[0]) {
-			runes := []rune(form)
-			runes[0] = unicode.ToUpper(runes[0])
-			return l.lemmatizeMEtape(string(runes), false, 1)
+			return l.lemmatizeMEtape(upperFirst(form), false, 1)
 		}
 	}
 
 	return mm
 }
 
-// lemmatizeText tokenizes text and lemmatizes each word token.
+// derive adds src to the Derivation of every analysis of mm, which it
+// returns.
+func derive(mm map[*Lemma][]Analysis, src Source) map[*Lemma][]Analysis {
+	for _, analyses := range mm {
+		for i := range analyses {
+			analyses[i].Derivation |= src
+		}
+	}
+	return mm
+}
+
+// mergeAnalyses adds the analyses of src to dst, allocating dst if
+// needed, without listing the same analysis twice for a lemma.
+func mergeAnalyses(dst, src map[*Lemma][]Analysis) map[*Lemma][]Analysis {
+	for lemma, analyses := range src {
+		if dst == nil {
+			dst = make(map[*Lemma][]Analysis)
+		}
+		dst[lemma] = DedupeAnalyses(append(dst[lemma], analyses...))
+	}
+	return dst
+}
+
+// lemmatizeText lemmatizes each word token of text.
 func (l *Lemmatizer) lemmatizeText(text string) []LemmatizationResult {
-	// Find all word tokens using a simple Unicode letter scanner
-	var results []LemmatizationResult
-	rePunct := regexp.MustCompile(`[.!?;:]`)
-	tokens := reWord.FindAllString(text, -1)
-	// Track sentence-start position by checking punctuation before each token
-	positions := reWord.FindAllStringIndex(text, -1)
-
-	for ti, token := range tokens {
-		debPhr := ti == 0
-		if !debPhr && positions[ti][0] > 0 {
-			before := text[:positions[ti][0]]
-			debPhr = rePunct.MatchString(before[max(0, len(before)-5):])
-		}
-		analyses := l.lemmatizeM(token, debPhr)
-		results = append(results, LemmatizationResult{
-			Token:    token,
-			Analyses: analyses,
-		})
+	return slices.Collect(l.tokens(text))
+}
+
+// tokens implements Tokens: the text is split at once, and each word
+// lemmatized when it is reached.
+func (l *Lemmatizer) tokens(text string) iter.Seq[LemmatizationResult] {
+	return func(yield func(LemmatizationResult) bool) {
+		tokens := TokenizeWithOptions(text, TokenizeOptions{JoinHyphenated: l.opts.JoinHyphenated})
+		inscription := l.opts.Inscriptions && mostlyCapitals(tokens)
+		for _, t := range tokens {
+			if t.IsWord && !yield(l.lemmatizeToken(t, inscription)) {
+				return
+			}
+		}
+	}
+}
+
+// lemmatizeToken lemmatizes the word token t, read as an inscription's
+// when inscription is set.
+func (l *Lemmatizer) lemmatizeToken(t Token, inscription bool) LemmatizationResult {
+	var analyses map[*Lemma][]Analysis
+	if inscription && isCapitals(t.Text) {
+		analyses = l.lemmatizeCapitals(capitalsForm(t.Text))
+	} else {
+		analyses = l.lemmatizeM(t.Text, t.SentenceStart)
+	}
+	return LemmatizationResult{
+		Token:    t.Text,
+		Analyses: analyses,
+		Start:    t.Start,
+		End:      t.End,
+
+		SentenceStart: t.SentenceStart,
+	}
+}
+
+// isCapitals reports whether word has upper-case letters and no
+// lower-case ones.
+func isCapitals(word string) bool {
+	return strings.IndexFunc(word, unicode.IsUpper) >= 0 && strings.IndexFunc(word, unicode.IsLower) < 0
+}
+
+// capitalsForm is the lower-case form under which a word written in
+// capitals is read. V is both u and v in capitals, so the v of the input
+// commits to nothing.
+func capitalsForm(word string) string {
+	return strings.ToLower(strings.ReplaceAll(word, "V", "U"))
+}
+
+// lemmatizeCapitals lemmatizes form, the capitalsForm of a word written
+// in capitals, with both its common and its proper-noun readings: the
+// capitals tell nothing of either, nor of a sentence start.
+func (l *Lemmatizer) lemmatizeCapitals(form string) map[*Lemma][]Analysis {
+	mm := mergeAnalyses(l.lemmatizeMEtape(form, false, 0), l.lemmatizeMEtape(upperFirst(form), false, 0))
+	return l.finishAnalyses(form, mm)
+}
+
+// mostlyCapitals reports whether more than three quarters of the words of
+// tokens are written in capitals, as in inscriptions.
+func mostlyCapitals(tokens []Token) bool {
+	words, caps := 0, 0
+	for _, t := range tokens {
+		if !t.IsWord {
+			continue
+		}
+		words++
+		if isCapitals(t.Text) {
+			caps++
+		}
 	}
-	return results
+	return caps*4 > words*3
 }
@@ -2,21 +2,118 @@ package collatinus
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+// source records the data file and 1-based line an entry was read from.
+type source struct {
+	file string
+	line int
+}
+
+// utf8BOM is the byte order mark some Windows editors write at the start
+// of UTF-8 files.
+var utf8BOM = []byte("\ufeff")
+
+// newScanner returns a line scanner over r that accepts LF, CRLF and
+// lone CR line endings and drops a leading byte order mark, so that data
+// files edited on Windows parse the same as the originals.
+func newScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	first := true
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := scanLines(data, atEOF)
+		if first && token != nil {
+			first = false
+			token = bytes.TrimPrefix(token, utf8BOM)
+		}
+		return advance, token, err
+	})
+	return sc
+}
+
+// scanLines is a bufio.SplitFunc like bufio.ScanLines that also ends a
+// line at a CR which is not followed by LF.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		switch {
+		case i+1 < len(data) && data[i+1] == '\n':
+			return i + 2, data[:i], nil
+		case i+1 < len(data) || atEOF:
+			return i + 1, data[:i], nil
+		}
+		// A CR at the end of the buffer: wait for the next byte.
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// openData opens the data file at path, or else its gzipped version
+// path+".gz", which shipped data may use to save space. The error is the
+// one for path when neither exists.
+func openData(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	gf, gerr := os.Open(path + ".gz")
+	if gerr != nil {
+		return nil, err
+	}
+	zr, gerr := gzip.NewReader(gf)
+	if gerr != nil {
+		gf.Close()
+		return nil, fmt.Errorf("%s.gz: %w", path, gerr)
+	}
+	return gzipFile{zr, gf}, nil
+}
+
+// gzipFile reads a gzipped file, closing both on Close.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g gzipFile) Close() error {
+	err := g.Reader.Close()
+	if ferr := g.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
 // loadMorphos reads data/morphos.fr into l.morphos (1-based).
-// Format: "n:description" (1-indexed), stops at "! --- " separator.
+// Format: "n:description" (1-indexed, in any order), stops at "! --- "
+// separator.
 // Mirrors LemCore::lisMorphos.
 func (l *Lemmatizer) loadMorphos(dataDir string) error {
-	f, err := os.Open(filepath.Join(dataDir, "morphos.fr"))
+	f, err := openData(filepath.Join(dataDir, "morphos.fr"))
 	if err != nil {
 		// fall back to morphos.la for compatibility
-		f2, err2 := os.Open(filepath.Join(dataDir, "morphos.la"))
+		f2, err2 := openData(filepath.Join(dataDir, "morphos.la"))
 		if err2 != nil {
 			return fmt.Errorf("open morphos.fr: %w", err)
 		}
@@ -24,7 +121,7 @@ func (l *Lemmatizer) loadMorphos(dataDir string) error {
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := newScanner(f)
 	for sc.Scan() {
 		line := sc.Text()
 		if strings.HasPrefix(line, "! --- ") {
@@ -37,7 +134,20 @@ func (l *Lemmatizer) loadMorphos(dataDir string) error {
 		if idx < 0 {
 			continue
 		}
-		l.morphos = append(l.morphos, line[idx+1:])
+		// The number, not the line order, is the morpho index the models
+		// refer to, so a reordered or extended file keeps its meaning.
+		n, err := strconv.Atoi(strings.TrimSpace(line[:idx]))
+		if err != nil || n < 1 {
+			n = len(l.morphos)
+		}
+		for len(l.morphos) <= n {
+			l.morphos = append(l.morphos, "")
+		}
+		l.morphos[n] = line[idx+1:]
+	}
+	l.morphologies = make([]Morphology, len(l.morphos))
+	for i, desc := range l.morphos {
+		l.morphologies[i] = parseMorphology(desc)
 	}
 	return sc.Err()
 }
@@ -46,14 +156,16 @@ func (l *Lemmatizer) loadMorphos(dataDir string) error {
 // Also registers all desinences into l.desinences.
 // Mirrors Lemmat::lisModeles.
 func (l *Lemmatizer) loadModels(dataDir string) error {
-	f, err := os.Open(filepath.Join(dataDir, "modeles.la"))
+	path := filepath.Join(dataDir, "modeles.la")
+	f, err := openData(path)
 	if err != nil {
 		return fmt.Errorf("open modeles.la: %w", err)
 	}
 	defer f.Close()
 
 	var block []string
-	sc := bufio.NewScanner(f)
+	var blockLine, lineNo int
+	sc := newScanner(f)
 	atEOF := false
 
 	flushBlock := func() {
@@ -62,6 +174,7 @@ func (l *Lemmatizer) loadModels(dataDir string) error {
 		}
 		m := l.parseModel(block)
 		if m != nil {
+			m.src = source{path, blockLine}
 			l.models[m.Name] = m
 		}
 		block = block[:0]
@@ -70,6 +183,7 @@ func (l *Lemmatizer) loadModels(dataDir string) error {
 	for !atEOF {
 		var line string
 		if sc.Scan() {
+			lineNo++
 			line = strings.TrimSpace(sc.Text())
 		} else {
 			atEOF = true
@@ -99,6 +213,9 @@ func (l *Lemmatizer) loadModels(dataDir string) error {
 		}
 
 		if !atEOF {
+			if len(block) == 0 {
+				blockLine = lineNo
+			}
 			block = append(block, line)
 		}
 	}
@@ -130,6 +247,7 @@ func (l *Lemmatizer) parseModel(lines []string) *Model {
 			}
 		case "pere":
 			if len(eclats) > 1 {
+				m.parentName = eclats[1]
 				m.parent = l.models[eclats[1]]
 			}
 		case "des", "des+":
@@ -160,7 +278,7 @@ func (l *Lemmatizer) parseModel(lines []string) *Model {
 						RadNum:    radNum,
 						Model:     m,
 					}
-					m.Desinences[mn] = append(m.Desinences[mn], d)
+					m.appendDesinence(d)
 					l.addDesinence(d)
 				}
 			}
@@ -170,7 +288,7 @@ func (l *Lemmatizer) parseModel(lines []string) *Model {
 				for _, mn := range morphoNums {
 					for _, dp := range m.parent.Desinences[mn] {
 						dc := cloneDesinence(dp, m)
-						m.Desinences[mn] = append(m.Desinences[mn], dc)
+						m.appendDesinence(dc)
 						l.addDesinence(dc)
 					}
 				}
@@ -226,7 +344,7 @@ func (l *Lemmatizer) parseModel(lines []string) *Model {
 					RadNum:    dp.RadNum,
 					Model:     m,
 				}
-				m.Desinences[dp.MorphoNum] = append(m.Desinences[dp.MorphoNum], d)
+				m.appendDesinence(d)
 				l.addDesinence(d)
 			}
 		}
@@ -248,7 +366,7 @@ func (l *Lemmatizer) parseModel(lines []string) *Model {
 					continue
 				}
 				dc := cloneDesinence(dp, m)
-				m.Desinences[mn] = append(m.Desinences[mn], dc)
+				m.appendDesinence(dc)
 				l.addDesinence(dc)
 			}
 		}
@@ -283,7 +401,7 @@ func (l *Lemmatizer) parseModel(lines []string) *Model {
 		}
 	}
 	for _, d := range sufDesSlice {
-		m.Desinences[d.MorphoNum] = append(m.Desinences[d.MorphoNum], d)
+		m.appendDesinence(d)
 		l.addDesinence(d)
 	}
 
@@ -317,14 +435,17 @@ func (l *Lemmatizer) substituteVars(line string) string {
 // loadLexicon reads bin/data/lemmes.la and builds l.lemmas and l.radicals.
 // Mirrors Lemmat::lisLexique.
 func (l *Lemmatizer) loadLexicon(dataDir string) error {
-	f, err := os.Open(filepath.Join(dataDir, "lemmes.la"))
+	path := filepath.Join(dataDir, "lemmes.la")
+	f, err := openData(path)
 	if err != nil {
 		return fmt.Errorf("open lemmes.la: %w", err)
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := newScanner(f)
+	lineNo := 0
 	for sc.Scan() {
+		lineNo++
 		line := strings.TrimSpace(sc.Text())
 		if line == "" || strings.HasPrefix(line, "!") {
 			continue
@@ -332,23 +453,41 @@ func (l *Lemmatizer) loadLexicon(dataDir string) error {
 
 		lemma := newLemma(line)
 		if lemma == nil {
+			if l.strict {
+				l.warn(source{path, lineNo}, "malformed lemma line")
+			}
 			continue
 		}
-
-		// Resolve model
-		lemma.model = l.models[lemma.modelName]
-		if lemma.model != nil && lemma.POS == POSUnknown {
-			lemma.POS = lemma.model.POS()
+		lemma.src = source{path, lineNo}
+		if l.strict && l.models[lemma.modelName] == nil {
+			l.warn(lemma.src, "lemma %s: unknown model %q", lemma.Key, lemma.modelName)
 		}
-
-		l.lemmas[lemma.Key] = lemma
-
-		// Build and register radicals
-		l.buildRadicals(lemma)
+		if lemma.posSource != POSFromData && strings.Count(line, "|") >= 6 {
+			if code := strings.Split(line, "|")[6]; code != "" {
+				l.warn(lemma.src, "lemma %s: unknown part-of-speech code %q", lemma.Key, code)
+			}
+		}
+		l.addLemma(lemma)
 	}
 	return sc.Err()
 }
 
+// addLemma resolves the model of a lemma read by newLemma and registers
+// the lemma and its radicals.
+func (l *Lemmatizer) addLemma(lemma *Lemma) {
+	lemma.model = l.models[lemma.modelName]
+	if lemma.model != nil && lemma.POS == POSUnknown {
+		if lemma.POS = lemma.model.POS(); lemma.POS != POSUnknown {
+			lemma.posSource = POSFromModel
+		}
+	}
+	setNumeral(lemma)
+	lemma.Deponent = isDeponent(lemma)
+
+	l.lemmas[lemma.Key] = lemma
+	l.buildRadicals(lemma)
+}
+
 // stemFromGrq computes the stem string from a canonical form (grq) and a radical
 // rule string ("K", "n", or "n,suffix"), mirroring the C++ radical derivation.
 func stemFromGrq(grq, rule string) string {
@@ -380,14 +519,25 @@ func (l *Lemmatizer) buildRadicals(lemma *Lemma) {
 	}
 
 	// First register explicit radicals already parsed from lemmes.la
-	for _, rads := range lemma.radicals {
+	for rn, rads := range lemma.radicals {
+		m.explicitRads[rn] = true
 		for _, r := range rads {
 			l.addRadical(r)
 		}
 	}
 
-	// Then compute radicals from the model's radical rules (skip if already explicit)
-	for rn, rule := range m.RadicalRules {
+	// Then those computed from the model's radical rules
+	for _, r := range deriveRadicals(lemma) {
+		l.addRadical(r)
+	}
+}
+
+// deriveRadicals adds to lemma the radicals its model's rules derive from
+// its canonical forms, for the radical numbers it has no explicit radical
+// for, and returns them.
+func deriveRadicals(lemma *Lemma) []*Radical {
+	var derived []*Radical
+	for rn, rule := range lemma.model.RadicalRules {
 		if _, exists := lemma.radicals[rn]; exists {
 			continue
 		}
@@ -395,21 +545,28 @@ func (l *Lemmatizer) buildRadicals(lemma *Lemma) {
 		// Iterate over the primary form and all alternative canonical forms,
 		// matching the C++ ajRadicaux which calls l->grq().split(',') and
 		// registers each derived radical on both the lemma and the global map.
+		// Forms yielding a stem already derived add nothing.
 		for _, grqForm := range append([]string{lemma.Grq}, lemma.altGrqs...) {
 			stem := stemFromGrq(grqForm, rule)
+			if lemma.hasRadical(rn, Atone(stem)) {
+				continue
+			}
 			r := &Radical{
-				Grq:   Communes(stem),
-				Gr:    Atone(stem),
-				Num:   rn,
-				Lemma: lemma,
+				Grq:     Communes(stem),
+				Gr:      Atone(stem),
+				Num:     rn,
+				Lemma:   lemma,
+				derived: true,
 			}
 			lemma.radicals[rn] = append(lemma.radicals[rn], r)
-			l.addRadical(r)
+			derived = append(derived, r)
 		}
 	}
+	return derived
 }
 
-// loadTranslations reads all lemmes.XX files from dataDir.
+// loadTranslations reads the lemmes.XX files of dataDir, those of the
+// languages New was asked for.
 // Mirrors Lemmat::lisTraductions.
 func (l *Lemmatizer) loadTranslations(dataDir string) error {
 	matches, err := filepath.Glob(filepath.Join(dataDir, "lemmes.*"))
@@ -417,11 +574,21 @@ func (l *Lemmatizer) loadTranslations(dataDir string) error {
 		return err
 	}
 	for _, path := range matches {
+		if plain, ok := strings.CutSuffix(path, ".gz"); ok {
+			// openData reads it, unless the plain file exists too.
+			if slices.Contains(matches, plain) {
+				continue
+			}
+			path = plain
+		}
 		ext := filepath.Ext(path)
 		if ext == ".la" || ext == "" {
 			continue
 		}
 		lang := ext[1:] // strip leading "."
+		if !l.config.wantsLanguage(lang) {
+			continue
+		}
 		if err := l.loadTranslationFile(path, lang); err != nil {
 			// Non-fatal: skip missing/malformed files
 			continue
@@ -430,16 +597,24 @@ func (l *Lemmatizer) loadTranslations(dataDir string) error {
 	return nil
 }
 
+// wantsLanguage reports whether the translations of lang are loaded.
+func (c loadConfig) wantsLanguage(lang string) bool {
+	if c.noTranslations {
+		return false
+	}
+	return c.languages == nil || slices.Contains(c.languages, lang)
+}
+
 // loadTranslationFile reads a single lemmes.XX file.
 // New format: first non-comment non-empty line is the language name (bare, no ! prefix).
 func (l *Lemmatizer) loadTranslationFile(path, lang string) error {
-	f, err := os.Open(path)
+	f, err := openData(path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := newScanner(f)
 	langNameSet := false
 
 	for sc.Scan() {
@@ -471,66 +646,121 @@ func (l *Lemmatizer) loadTranslationFile(path, lang string) error {
 	return sc.Err()
 }
 
-// loadIrregs reads bin/data/irregs.la and populates l.irregs.
-// Mirrors Lemmat::lisIrreguliers.
-func (l *Lemmatizer) loadIrregs(dataDir string) error {
-	f, err := os.Open(filepath.Join(dataDir, "irregs.la"))
+// loadGlossesTSV reads a combined glosses file of
+// "lemmaKey<TAB>lang<TAB>gloss" rows. Rows with an unknown key or missing
+// fields are skipped with a warning.
+func (l *Lemmatizer) loadGlossesTSV(path string) error {
+	f, err := openData(path)
 	if err != nil {
-		return fmt.Errorf("open irregs.la: %w", err)
+		return err
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := newScanner(f)
+	lineNo := 0
 	for sc.Scan() {
+		lineNo++
 		line := strings.TrimSpace(sc.Text())
 		if line == "" || strings.HasPrefix(line, "!") {
 			continue
 		}
-
-		parts := strings.Split(line, ":")
-		if len(parts) < 3 {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 || fields[1] == "" {
+			l.warn(source{path, lineNo}, "expected key, language and gloss separated by tabs")
 			continue
 		}
-
-		grq := parts[0]
-		exclusive := strings.HasSuffix(grq, "*")
-		if exclusive {
-			grq = grq[:len(grq)-1]
-		}
-		gr := Atone(grq)
-
-		lemmaKey := Deramise(parts[1])
-		lemma := l.lemmas[lemmaKey]
+		key, lang, gloss := fields[0], fields[1], strings.TrimSpace(fields[2])
+		lemma := l.lemmas[NormalizeKey(key)]
 		if lemma == nil {
+			l.warn(source{path, lineNo}, "unknown lemma %q", key)
 			continue
 		}
+		if _, ok := l.languages[lang]; !ok {
+			l.languages[lang] = lang
+		}
+		lemma.AddTranslation(lang, gloss)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	l.fingerprint = l.computeFingerprint()
+	return nil
+}
 
-		irr := &Irreg{
-			Grq:       grq,
-			Gr:        gr,
-			Exclusive: exclusive,
-			Lemma:     lemma,
-			Morphos:   ListI(parts[2]),
+// loadIrregs reads bin/data/irregs.la and populates l.irregs.
+// Mirrors Lemmat::lisIrreguliers.
+func (l *Lemmatizer) loadIrregs(dataDir string) error {
+	path := filepath.Join(dataDir, "irregs.la")
+	f, err := openData(path)
+	if err != nil {
+		return fmt.Errorf("open irregs.la: %w", err)
+	}
+	defer f.Close()
+
+	sc := newScanner(f)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "!") {
+			continue
 		}
 
-		key := Deramise(gr)
-		l.irregs[key] = append(l.irregs[key], irr)
-		lemma.addIrreg(irr)
+		if irr := l.parseIrreg(line); irr != nil {
+			irr.src = source{path, lineNo}
+			l.addIrreg(irr)
+		} else if l.strict {
+			l.warn(source{path, lineNo}, "malformed irregular form or unknown lemma")
+		}
 	}
 	return sc.Err()
 }
 
+// parseIrreg parses a line of irregs.la, "form[*]:lemma:morphos". It
+// returns nil when the line is malformed or names an unknown lemma.
+func (l *Lemmatizer) parseIrreg(line string) *Irreg {
+	parts := strings.Split(line, ":")
+	if len(parts) < 3 {
+		return nil
+	}
+
+	grq := parts[0]
+	exclusive := strings.HasSuffix(grq, "*")
+	if exclusive {
+		grq = grq[:len(grq)-1]
+	}
+
+	lemma := l.lemmas[Deramise(parts[1])]
+	if lemma == nil {
+		return nil
+	}
+	return &Irreg{
+		Grq:       grq,
+		Gr:        Atone(grq),
+		Exclusive: exclusive,
+		Lemma:     lemma,
+		Morphos:   ListI(parts[2]),
+	}
+}
+
+// addIrreg registers an irregular form, on its lemma too.
+func (l *Lemmatizer) addIrreg(irr *Irreg) {
+	key := Deramise(irr.Gr)
+	l.irregs[key] = append(l.irregs[key], irr)
+	irr.Lemma.addIrreg(irr)
+}
+
 // loadAssims reads data/assimilations.la and populates l.assims.
 // Format: "key:value" with quantity marks; stored as atone forms.
 // Mirrors LemCore::ajAssims.
 func (l *Lemmatizer) loadAssims(dataDir string) error {
-	f, err := os.Open(filepath.Join(dataDir, "assimilations.la"))
+	f, err := openData(filepath.Join(dataDir, "assimilations.la"))
 	if err != nil {
 		return fmt.Errorf("open assimilations.la: %w", err)
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := newScanner(f)
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
 		if line == "" || strings.HasPrefix(line, "!") {
@@ -551,13 +781,13 @@ func (l *Lemmatizer) loadAssims(dataDir string) error {
 // Format: "key:value" (without quantity marks).
 // Mirrors LemCore::ajContractions.
 func (l *Lemmatizer) loadContractions(dataDir string) error {
-	f, err := os.Open(filepath.Join(dataDir, "contractions.la"))
+	f, err := openData(filepath.Join(dataDir, "contractions.la"))
 	if err != nil {
 		return fmt.Errorf("open contractions.la: %w", err)
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := newScanner(f)
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
 		if line == "" || strings.HasPrefix(line, "!") {
@@ -571,3 +801,19 @@ func (l *Lemmatizer) loadContractions(dataDir string) error {
 	}
 	return sc.Err()
 }
+
+// linkReferences resolves the "cf." cross-reference of every lemma to the
+// lemma it names, filling refsOut and refsIn. Keys naming no lemma are
+// left unresolved.
+func (l *Lemmatizer) linkReferences() {
+	for _, k := range slices.Sorted(maps.Keys(l.lemmas)) {
+		lemma := l.lemmas[k]
+		if lemma.renvoi == "" {
+			continue
+		}
+		if target := l.lemmas[NormalizeKey(lemma.renvoi)]; target != nil && target != lemma {
+			lemma.refsOut = append(lemma.refsOut, target)
+			target.refsIn = append(target.refsIn, lemma)
+		}
+	}
+}
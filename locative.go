@@ -0,0 +1,38 @@
+package collatinus
+
+import (
+	"maps"
+	"slices"
+)
+
+// extraLocatives lists, by lemma key, the locatives of common words
+// whose model has none. The models encode the others: roma gives Rōmāe,
+// domus domī and corpus rūrī.
+var extraLocatives = map[string]string{
+	"humus":    "hŭmī",
+	"bellum":   "bēllī",
+	"militia":  "mīlĭtĭāe",
+	"Carthago": "Cārthāgĭnī",
+	"Athenae":  "Āthēnīs",
+}
+
+// addLocatives registers the forms of extraLocatives as irregular forms
+// of their lemmas, unless the model or the data already give one. They
+// come from no data file, so their Source is empty.
+func (l *Lemmatizer) addLocatives() {
+	loc := slices.Index(l.morphos, "locatif")
+	if loc < 0 {
+		return
+	}
+	for _, key := range slices.Sorted(maps.Keys(extraLocatives)) {
+		lemma := l.lemmas[key]
+		if lemma == nil || lemma.model == nil || len(lemma.model.DesinencesAt(loc)) > 0 {
+			continue
+		}
+		if grq, _ := lemma.irregAt(loc); grq != "" {
+			continue
+		}
+		grq := extraLocatives[key]
+		l.addIrreg(&Irreg{Grq: grq, Gr: Atone(grq), Lemma: lemma, Morphos: []int{loc}})
+	}
+}
@@ -0,0 +1,87 @@
+package collatinus
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// macrons maps a vowel to its long form.
+var macrons = map[rune]rune{
+	'a': 'ā', 'e': 'ē', 'i': 'ī', 'o': 'ō', 'u': 'ū', 'y': 'ȳ',
+	'A': 'Ā', 'E': 'Ē', 'I': 'Ī', 'O': 'Ō', 'U': 'Ū', 'Y': 'Ȳ',
+}
+
+// macronize implements Macronize.
+func (l *Lemmatizer) macronize(text string) string {
+	text = norm.NFC.String(text)
+	var b strings.Builder
+	pos := 0
+	for _, res := range l.lemmatizeText(text) {
+		// A word rejoined across a line break keeps its spelling.
+		if text[res.Start:res.End] != res.Token {
+			continue
+		}
+		b.WriteString(text[pos:res.Start])
+		b.WriteString(macronizeWord(res.Token, res.Analyses))
+		pos = res.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// macronizeWord marks the long vowels of word on which agree the
+// analyses that spell its letters, an enclitic aside, of its lemmas of
+// highest NbOcc.
+func macronizeWord(word string, mm map[*Lemma][]Analysis) string {
+	key := Deramise(Atone(strings.ToLower(word)))
+	var long []bool
+	bestOcc := -1
+	for lemma, analyses := range mm {
+		for _, a := range analyses {
+			rest, ok := strings.CutPrefix(key, Deramise(Atone(strings.ToLower(a.FormWithMarks))))
+			if !ok || rest != "" && !slices.Contains(enclitics, rest) {
+				continue
+			}
+			marks := append(longVowels(a.FormWithMarks), make([]bool, len(rest))...)
+			switch {
+			case len(marks) != len(key) || lemma.NbOcc < bestOcc:
+			case lemma.NbOcc > bestOcc:
+				long, bestOcc = marks, lemma.NbOcc
+			default:
+				for i := range long {
+					long[i] = long[i] && marks[i]
+				}
+			}
+		}
+	}
+	if long == nil {
+		return word
+	}
+
+	var out []rune
+	i := 0
+	for _, r := range word {
+		if m, ok := macrons[r]; ok && long[i] {
+			r = m
+		}
+		out = append(out, r)
+		i += len(Deramise(Atone(string(unicode.ToLower(r)))))
+	}
+	return string(out)
+}
+
+// longVowels tells, for each letter of the deramised spelling of form,
+// whether it is a vowel marked long, as dictionaryMarks has it.
+func longVowels(form string) []bool {
+	var long []bool
+	for _, letter := range letters(dictionaryMarks(form)) {
+		bare := Deramise(Atone(letter))
+		for range bare {
+			long = append(long, bare != letter && len(bare) == 1)
+		}
+	}
+	return long
+}
@@ -0,0 +1,92 @@
+package collatinus
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// FormMatch is a generable form matching a FindFormsMatching pattern.
+type FormMatch struct {
+	// Form is the form with vowel-quantity marks.
+	Form string
+	// Lemma is the lemma the form inflects.
+	Lemma *Lemma
+	// MorphoIndex is the 1-based morpho index the form realizes.
+	MorphoIndex int
+}
+
+// findFormsMatching implements FindFormsMatching.
+func (l *Lemmatizer) findFormsMatching(pattern string, limit int) ([]FormMatch, error) {
+	if l.index == nil {
+		return nil, errors.New("FindFormsMatching needs the form index: call BuildFormIndex or LoadFormIndex")
+	}
+	pat := []rune(strings.ToLower(NormalizeKey(pattern)))
+	// Only the keys are collected, so that "*" costs no more than a scan
+	// of the index; their analyses are expanded up to limit.
+	var keys []string
+	for key := range l.index {
+		if globMatch(pat, []rune(strings.ToLower(key))) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var matches []FormMatch
+	for _, key := range keys {
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+		seen := make(map[FormMatch]bool)
+		var ms []FormMatch
+		for _, e := range l.index[key] {
+			m := FormMatch{Form: e.analysis.FormWithMarks, Lemma: e.lemma, MorphoIndex: e.analysis.MorphoIndex}
+			if !seen[m] {
+				seen[m] = true
+				ms = append(ms, m)
+			}
+		}
+		sort.Slice(ms, func(i, j int) bool {
+			a, b := ms[i], ms[j]
+			if a.Lemma != b.Lemma {
+				return a.Lemma.Key < b.Lemma.Key
+			}
+			if a.MorphoIndex != b.MorphoIndex {
+				return a.MorphoIndex < b.MorphoIndex
+			}
+			return a.Form < b.Form
+		})
+		matches = append(matches, ms...)
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// globMatch tells whether s matches pattern, where '?' stands for any one
+// letter and '*' for any run of letters, possibly empty.
+func globMatch(pattern, s []rune) bool {
+	// star and mark are the positions after the last '*' and in s where
+	// it started matching, to backtrack to on a mismatch.
+	star, mark := -1, 0
+	p, i := 0, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p+1, i
+			p++
+		case star >= 0:
+			mark++
+			p, i = star, mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
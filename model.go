@@ -1,6 +1,8 @@
 package collatinus
 
 import (
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -39,6 +41,10 @@ type Desinence struct {
 	RadNum int
 	// Model is the model that owns this desinence (important for matching).
 	Model *Model
+	// Variant is true when another desinence was listed before this one for
+	// the same morpho: the first one listed in modeles.la is the primary
+	// (textbook-standard) ending, the following ones are variants.
+	Variant bool
 }
 
 // Model represents an inflection paradigm.
@@ -48,6 +54,9 @@ type Model struct {
 	Name string
 	// parent is the inherited-from model (nil for root models).
 	parent *Model
+	// parentName is the model named by the "pere:" directive, which must
+	// be defined before this one to become its parent.
+	parentName string
 	// RadicalRules maps radical-number → rule string.
 	// Rule "K" means use canonical form as-is; otherwise "n,suffix"
 	// means remove n chars from end and append suffix.
@@ -58,6 +67,11 @@ type Model struct {
 	Desinences map[int][]*Desinence
 	// pos is the part-of-speech character set from "pos:" directive.
 	pos rune
+	// explicitRads records the radical numbers given explicitly in
+	// lemmes.la by at least one lemma of this model.
+	explicitRads map[int]bool
+	// src points at the "modele:" line of the model in modeles.la.
+	src source
 }
 
 // newModel creates an empty Model with the given name.
@@ -66,6 +80,7 @@ func newModel(name string) *Model {
 		Name:         name,
 		RadicalRules: make(map[int]string),
 		Desinences:   make(map[int][]*Desinence),
+		explicitRads: make(map[int]bool),
 	}
 }
 
@@ -75,6 +90,13 @@ func (m *Model) hasDesinence(morphoNum int) bool {
 	return ok
 }
 
+// appendDesinence adds d to the model's desinences for its morpho index,
+// marking it as a variant if a desinence is already listed there.
+func (m *Model) appendDesinence(d *Desinence) {
+	d.Variant = len(m.Desinences[d.MorphoNum]) > 0
+	m.Desinences[d.MorphoNum] = append(m.Desinences[d.MorphoNum], d)
+}
+
 // isAbsent returns true if morpho index a is absent in this model.
 func (m *Model) isAbsent(a int) bool {
 	for _, v := range m.Absents {
@@ -99,22 +121,72 @@ func (m *Model) AllDesinences() []*Desinence {
 	return result
 }
 
+// UnreachableMorphos returns, sorted, the morpho indices whose desinences
+// all use a radical number for which the model has no radical rule and
+// no lemma gives an explicit radical: such cells can never be generated.
+func (m *Model) UnreachableMorphos() []int {
+	var out []int
+	for mn, des := range m.Desinences {
+		reachable := false
+		for _, d := range des {
+			if _, ok := m.RadicalRules[d.RadNum]; ok || m.explicitRads[d.RadNum] {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			out = append(out, mn)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
 // Parent returns the parent model.
 func (m *Model) Parent() *Model {
 	return m.parent
 }
 
+// Source returns the data file and 1-based line of the model's
+// "modele:" directive.
+func (m *Model) Source() (file string, line int) {
+	return m.src.file, m.src.line
+}
+
 // EstUn returns true if this model or any ancestor has the given name.
+// A circular chain of parents is walked once.
 func (m *Model) EstUn(name string) bool {
-	if m.Name == name {
-		return true
-	}
-	if m.parent != nil {
-		return m.parent.EstUn(name)
+	var seen [8]*Model
+	visited := seen[:0]
+	for a := m; a != nil && !slices.Contains(visited, a); a = a.parent {
+		if a.Name == name {
+			return true
+		}
+		visited = append(visited, a)
 	}
 	return false
 }
 
+// parentCycle returns the names of the models of the circular chain of
+// parents leading from m back to m, m first, or nil when there is none.
+func (m *Model) parentCycle() []string {
+	var chain []*Model
+	for a := m; a != nil; a = a.parent {
+		if i := slices.Index(chain, a); i >= 0 {
+			if i > 0 {
+				return nil // a cycle among the ancestors of m only
+			}
+			names := make([]string, len(chain))
+			for j, c := range chain {
+				names[j] = c.Name
+			}
+			return names
+		}
+		chain = append(chain, a)
+	}
+	return nil
+}
+
 // POS returns the part-of-speech for this model.
 // If a pos directive was set, that takes precedence; otherwise infers from ancestry.
 func (m *Model) POS() PartOfSpeech {
@@ -134,6 +206,27 @@ func (m *Model) POS() PartOfSpeech {
 	return POSUnknown
 }
 
+// lemmasByModel implements LemmasByModel.
+func (l *Lemmatizer) lemmasByModel(name string, includeDerived bool) []*Lemma {
+	var lemmas []*Lemma
+	for _, lemma := range l.lemmas {
+		m := lemma.model
+		if m == nil {
+			continue
+		}
+		if m.Name == name || includeDerived && m.EstUn(name) {
+			lemmas = append(lemmas, lemma)
+		}
+	}
+	sort.Slice(lemmas, func(i, j int) bool {
+		if lemmas[i].NbOcc != lemmas[j].NbOcc {
+			return lemmas[i].NbOcc > lemmas[j].NbOcc
+		}
+		return lemmas[i].Key < lemmas[j].Key
+	})
+	return lemmas
+}
+
 // cloneDesinence creates a copy of d with Model set to newModel.
 func cloneDesinence(d *Desinence, newModel *Model) *Desinence {
 	return &Desinence{
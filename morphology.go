@@ -0,0 +1,199 @@
+package collatinus
+
+// Morphology is a morpho description split into its features; see
+// ParseMorpho. The features a description does not give are zero.
+type Morphology struct {
+	Case   Case
+	Number Number
+	Gender Gender
+	Degree Degree
+	Tense  Tense
+	// Mood also tells the forms that are not finite: infinitive,
+	// participle, gerund, gerundive and supine.
+	Mood  Mood
+	Voice Voice
+	// Person is 1, 2 or 3.
+	Person int
+}
+
+// Case is the case of a Morphology.
+type Case int
+
+const (
+	CaseUnspecified Case = iota
+	CaseNominative
+	CaseVocative
+	CaseAccusative
+	CaseGenitive
+	CaseDative
+	CaseAblative
+	CaseLocative
+)
+
+// Number is the number of a Morphology.
+type Number int
+
+const (
+	NumberUnspecified Number = iota
+	NumberSingular
+	NumberPlural
+)
+
+// Gender is the gender of a Morphology.
+type Gender int
+
+const (
+	GenderUnspecified Gender = iota
+	GenderMasculine
+	GenderFeminine
+	GenderNeuter
+)
+
+// Degree is the degree of comparison of a Morphology.
+type Degree int
+
+const (
+	DegreeUnspecified Degree = iota
+	DegreePositive
+	DegreeComparative
+	DegreeSuperlative
+)
+
+// Tense is the tense of a Morphology.
+type Tense int
+
+const (
+	TenseUnspecified Tense = iota
+	TensePresent
+	TenseImperfect
+	TenseFuture
+	TensePerfect
+	TensePluperfect
+	TenseFuturePerfect
+)
+
+// Mood is the mood, or the non-finite form, of a Morphology.
+type Mood int
+
+const (
+	MoodUnspecified Mood = iota
+	MoodIndicative
+	MoodSubjunctive
+	MoodImperative
+	MoodInfinitive
+	MoodParticiple
+	MoodGerund
+	MoodGerundive
+	MoodSupine
+)
+
+// Voice is the voice of a Morphology.
+type Voice int
+
+const (
+	VoiceUnspecified Voice = iota
+	VoiceActive
+	VoicePassive
+)
+
+// udMorphology sets the feature each UD feature of udFeatures names, but
+// for the tenses that depend on the aspect too, which parseMorphology
+// sets itself.
+var udMorphology = map[string]func(*Morphology){
+	"Person=1": func(m *Morphology) { m.Person = 1 },
+	"Person=2": func(m *Morphology) { m.Person = 2 },
+	"Person=3": func(m *Morphology) { m.Person = 3 },
+
+	"Case=Nom": func(m *Morphology) { m.Case = CaseNominative },
+	"Case=Voc": func(m *Morphology) { m.Case = CaseVocative },
+	"Case=Acc": func(m *Morphology) { m.Case = CaseAccusative },
+	"Case=Gen": func(m *Morphology) { m.Case = CaseGenitive },
+	"Case=Dat": func(m *Morphology) { m.Case = CaseDative },
+	"Case=Abl": func(m *Morphology) { m.Case = CaseAblative },
+	"Case=Loc": func(m *Morphology) { m.Case = CaseLocative },
+
+	"Number=Sing": func(m *Morphology) { m.Number = NumberSingular },
+	"Number=Plur": func(m *Morphology) { m.Number = NumberPlural },
+
+	"Gender=Masc": func(m *Morphology) { m.Gender = GenderMasculine },
+	"Gender=Fem":  func(m *Morphology) { m.Gender = GenderFeminine },
+	"Gender=Neut": func(m *Morphology) { m.Gender = GenderNeuter },
+
+	"Degree=Pos": func(m *Morphology) { m.Degree = DegreePositive },
+	"Degree=Cmp": func(m *Morphology) { m.Degree = DegreeComparative },
+	"Degree=Sup": func(m *Morphology) { m.Degree = DegreeSuperlative },
+
+	"Tense=Pres": func(m *Morphology) { m.Tense = TensePresent },
+	"Tense=Fut":  func(m *Morphology) { m.Tense = TenseFuture },
+	"Tense=Pqp":  func(m *Morphology) { m.Tense = TensePluperfect },
+
+	"Mood=Ind":      func(m *Morphology) { m.Mood = MoodIndicative },
+	"Mood=Sub":      func(m *Morphology) { m.Mood = MoodSubjunctive },
+	"Mood=Imp":      func(m *Morphology) { m.Mood = MoodImperative },
+	"VerbForm=Inf":  func(m *Morphology) { m.Mood = MoodInfinitive },
+	"VerbForm=Part": func(m *Morphology) { m.Mood = MoodParticiple },
+	"VerbForm=Ger":  func(m *Morphology) { m.Mood = MoodGerund },
+	"VerbForm=Gdv":  func(m *Morphology) { m.Mood = MoodGerundive },
+	"VerbForm=Sup":  func(m *Morphology) { m.Mood = MoodSupine },
+
+	"Voice=Act":  func(m *Morphology) { m.Voice = VoiceActive },
+	"Voice=Pass": func(m *Morphology) { m.Voice = VoicePassive },
+}
+
+// parseMorphology returns the features of the morpho description desc,
+// read through its UD features, ignoring the words it does not know.
+func parseMorphology(desc string) Morphology {
+	var m Morphology
+	feats := featureMap(desc)
+	for name, value := range feats {
+		if set, ok := udMorphology[name+"="+value]; ok {
+			set(&m)
+		}
+	}
+	switch feats["Tense"] + " " + feats["Aspect"] {
+	case "Past Imp":
+		m.Tense = TenseImperfect
+	case "Past Perf":
+		m.Tense = TensePerfect
+	case "Fut Perf":
+		m.Tense = TenseFuturePerfect
+	}
+	return m
+}
+
+// morphologyOf returns the features of morpho index m as it applies to
+// lemma, active for a deponent as MorphoOf has it.
+func (l *Lemmatizer) morphologyOf(lemma *Lemma, m int) Morphology {
+	mo := l.ParseMorpho(m)
+	if lemma != nil && lemma.Deponent && mo.Voice == VoicePassive {
+		mo.Voice = VoiceActive
+	}
+	return mo
+}
+
+// matches reports whether m has every feature filter specifies, the
+// Unspecified fields of filter matching anything.
+func (m Morphology) matches(filter Morphology) bool {
+	return (filter.Case == 0 || m.Case == filter.Case) &&
+		(filter.Number == 0 || m.Number == filter.Number) &&
+		(filter.Gender == 0 || m.Gender == filter.Gender) &&
+		(filter.Degree == 0 || m.Degree == filter.Degree) &&
+		(filter.Tense == 0 || m.Tense == filter.Tense) &&
+		(filter.Mood == 0 || m.Mood == filter.Mood) &&
+		(filter.Voice == 0 || m.Voice == filter.Voice) &&
+		(filter.Person == 0 || m.Person == filter.Person)
+}
+
+// formsFor implements FormsFor.
+func (l *Lemmatizer) formsFor(lemma *Lemma, filter Morphology) []string {
+	if lemma == nil {
+		return nil
+	}
+	var forms []string
+	for mn := 1; mn < len(l.morphos); mn++ {
+		if l.morphologyOf(lemma, mn).matches(filter) {
+			forms = append(forms, l.inflectedForms(lemma, mn)...)
+		}
+	}
+	return unique(forms)
+}
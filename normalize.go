@@ -2,8 +2,11 @@ package collatinus
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // atoneReplacer removes all vowel quantity marks (macrons and breves)
@@ -22,6 +25,7 @@ var atoneReplacer = strings.NewReplacer(
 	"\u016d", "u", // ŭ → u
 	"\u0233", "y", // ȳ → y
 	"\u045e", "y", // ў → y
+	"\u01e3", "\u00e6", // ǣ → æ
 	// uppercase macrons and breves
 	"\u0100", "A", // Ā → A
 	"\u0102", "A", // Ă → A
@@ -35,6 +39,7 @@ var atoneReplacer = strings.NewReplacer(
 	"\u016c", "U", // Ŭ → U
 	"\u0232", "Y", // Ȳ → Y
 	"\u040e", "Y", // Ў → Y
+	"\u01e2", "\u00c6", // Ǣ → Æ
 )
 
 // Atone strips all vowel-quantity diacritics from s, mirroring Ch::atone.
@@ -109,10 +114,97 @@ func Communes(g string) string {
 	return lower
 }
 
+// upperFirst returns s with its first rune upper-cased. Ligatures are
+// single runes (æ → Æ, œ → Œ), so they need no special treatment.
+func upperFirst(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// titleCase returns s with its first rune upper-cased and all the others
+// lower-cased, turning "ÆNEAS" or "OEdipus" into "Æneas" and "Oedipus".
+func titleCase(s string) string {
+	return upperFirst(strings.ToLower(s))
+}
+
+// hasInnerUpper reports whether any rune of s after the first is upper-case.
+func hasInnerUpper(s string) bool {
+	for i, r := range []rune(s) {
+		if i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
 // NormalizeKey returns the canonical lookup key for a lemma entry:
 // atone first, then deramise (matching _cle = Ch::atone(Ch::deramise(key))).
-// In practice both orderings are equivalent since atone and deramise
-// operate on disjoint character sets.
+// Both orderings are equivalent except for the macron ligatures ǣ/Ǣ,
+// which atone turns into æ/Æ so that deramise can expand them.
 func NormalizeKey(s string) string {
-	return Atone(Deramise(s))
+	return Deramise(Atone(s))
+}
+
+// QuantityCompatible reports whether userForm, as typed, may be the
+// marked form: their letters must be the same under Atone, and each
+// vowel userForm marks long or short must be marked the same way in
+// marked, or left unmarked or common there. Vowels userForm leaves
+// unmarked match anything, so "rosa" and "rosā" fit rŏsā but "rosă"
+// does not. Both forms are composed to NFC first, so that combining
+// marks may be typed.
+func QuantityCompatible(userForm, marked string) bool {
+	ub, uq := quantityMarks(userForm)
+	mb, mq := quantityMarks(marked)
+	if !slices.Equal(ub, mb) {
+		return false
+	}
+	for i, q := range uq {
+		if q != QuantityCommon && mq[i] != QuantityCommon && mq[i] != q {
+			return false
+		}
+	}
+	return true
+}
+
+// quantityMarks splits s into its letters under Atone and the quantity
+// each one is marked with, a macron and a breve together making it
+// common.
+func quantityMarks(s string) ([]rune, []Quantity) {
+	var bases []rune
+	var qs []Quantity
+	var marked []bool
+	for _, r := range norm.NFC.String(s) {
+		if r == '\u0304' || r == '\u0306' {
+			n := len(qs)
+			if n == 0 {
+				continue
+			}
+			mark := QuantityShort
+			if r == '\u0304' {
+				mark = QuantityLong
+			}
+			switch {
+			case !marked[n-1]:
+				qs[n-1], marked[n-1] = mark, true
+			case qs[n-1] != mark:
+				qs[n-1] = QuantityCommon
+			}
+			continue
+		}
+		q := QuantityCommon
+		switch lower := unicode.ToLower(r); {
+		case strings.ContainsRune(macronVowels, lower):
+			q = QuantityLong
+		case strings.ContainsRune(breveVowels, lower):
+			q = QuantityShort
+		}
+		bases = append(bases, []rune(Atone(string(r)))[0])
+		qs = append(qs, q)
+		marked = append(marked, q != QuantityCommon)
+	}
+	return bases, qs
 }
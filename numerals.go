@@ -0,0 +1,97 @@
+package collatinus
+
+// numeralValues maps Deramise(lemma.Gr) of cardinal and ordinal numerals
+// to their numeric value. Compound numerals written as several words
+// (tertius decimus, viginti unus…) are not lemmas and are not listed.
+var numeralValues = map[string]int{
+	// cardinals
+	"unus":          1,
+	"duo":           2,
+	"tres":          3,
+	"quattuor":      4,
+	"quinque":       5,
+	"sex":           6,
+	"septem":        7,
+	"octo":          8,
+	"nouem":         9,
+	"decem":         10,
+	"undecim":       11,
+	"duodecim":      12,
+	"tredecim":      13,
+	"quattuordecim": 14,
+	"quindecim":     15,
+	"sedecim":       16,
+	"septendecim":   17,
+	"duodeuiginti":  18,
+	"undeuiginti":   19,
+	"uiginti":       20,
+	"triginta":      30,
+	"quadraginta":   40,
+	"quinquaginta":  50,
+	"sexaginta":     60,
+	"septuaginta":   70,
+	"octoginta":     80,
+	"nonaginta":     90,
+	"centum":        100,
+	"ducenti":       200,
+	"trecenti":      300,
+	"quadringenti":  400,
+	"quingenti":     500,
+	"sescenti":      600,
+	"septingenti":   700,
+	"octingenti":    800,
+	"nongenti":      900,
+	"mille":         1000,
+
+	// ordinals
+	"primus":            1,
+	"secundus":          2,
+	"tertius":           3,
+	"quartus":           4,
+	"quintus":           5,
+	"sextus":            6,
+	"septimus":          7,
+	"octauus":           8,
+	"nonus":             9,
+	"decimus":           10,
+	"undecimus":         11,
+	"duodecimus":        12,
+	"duodeuicesimus":    18,
+	"undeuicesimus":     19,
+	"uicesimus":         20,
+	"tricesimus":        30,
+	"quadragesimus":     40,
+	"quinquagesimus":    50,
+	"sexagesimus":       60,
+	"septuagesimus":     70,
+	"octogesimus":       80,
+	"nonagesimus":       90,
+	"centesimus":        100,
+	"ducentesimus":      200,
+	"trecentesimus":     300,
+	"quadringentesimus": 400,
+	"quingentesimus":    500,
+	"sescentesimus":     600,
+	"septingentesimus":  700,
+	"octingentesimus":   800,
+	"nongentesimus":     900,
+	"millesimus":        1000,
+}
+
+// setNumeral marks lemma as a numeral if it is listed in numeralValues.
+// Nouns and verbs sharing a numeral's spelling are left alone, and so is
+// a part of speech the lexicon line gives.
+func setNumeral(lemma *Lemma) {
+	if lemma.POS == POSNoun || lemma.POS == POSVerb {
+		return
+	}
+	if lemma.posSource == POSFromData && lemma.POS != POSNumeral {
+		return
+	}
+	if v, ok := numeralValues[Deramise(lemma.Gr)]; ok {
+		lemma.numValue = v
+		if lemma.posSource != POSFromData {
+			lemma.POS, lemma.posSource = POSNumeral, POSFromHeuristic
+		}
+	}
+}
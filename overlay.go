@@ -0,0 +1,271 @@
+package collatinus
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// applyOverlay implements ApplyOverlay.
+func (l *Lemmatizer) applyOverlay(path string) error {
+	f, err := openData(path)
+	if err != nil {
+		return fmt.Errorf("open overlay: %w", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	sc := newScanner(f)
+	for sc.Scan() {
+		lines = append(lines, strings.TrimSpace(sc.Text()))
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	applied := 0
+	defer func() {
+		if applied > 0 {
+			l.refreshAfterOverlay()
+		}
+	}()
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" || strings.HasPrefix(line, "!") {
+			continue
+		}
+		src := source{path, i + 1}
+		action, rest, _ := strings.Cut(line, ":")
+		entity, payload, ok := strings.Cut(rest, ":")
+		if !ok || action != "add" && action != "override" && action != "delete" {
+			return fmt.Errorf("%s:%d: expected add, override or delete:entity:data", path, src.line)
+		}
+		switch entity {
+		case "lemma":
+			err = l.overlayLemma(action, payload, src)
+		case "irreg":
+			err = l.overlayIrreg(action, payload, src)
+		case "model":
+			// The lines of the model follow, up to a blank line.
+			var body []string
+			for action != "delete" && i+1 < len(lines) && lines[i+1] != "" {
+				i++
+				if !strings.HasPrefix(lines[i], "!") {
+					body = append(body, lines[i])
+				}
+			}
+			err = l.overlayModel(action, payload, body, src)
+		default:
+			err = fmt.Errorf("unknown entity %q", entity)
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, src.line, err)
+		}
+		applied++
+	}
+	return nil
+}
+
+// overlayLemma applies an overlay directive to a lemma: data is a line
+// of lemmes.la, or the key of the lemma to delete. An overriding lemma
+// keeps the translations and irregular forms of the one it replaces.
+func (l *Lemmatizer) overlayLemma(action, data string, src source) error {
+	if action == "delete" {
+		lemma := l.lemmas[NormalizeKey(data)]
+		if lemma == nil {
+			return fmt.Errorf("no lemma %q to delete", data)
+		}
+		l.removeLemma(lemma)
+		return nil
+	}
+
+	lemma := newLemma(data)
+	if lemma == nil {
+		return fmt.Errorf("malformed lemma %q", data)
+	}
+	if l.models[lemma.modelName] == nil {
+		return fmt.Errorf("lemma %s: unknown model %q", lemma.Key, lemma.modelName)
+	}
+	old := l.lemmas[lemma.Key]
+	switch {
+	case action == "add" && old != nil:
+		return fmt.Errorf("lemma %s already exists", lemma.Key)
+	case action == "override" && old == nil:
+		return fmt.Errorf("no lemma %s to override", lemma.Key)
+	}
+	lemma.src = src
+	var irregs []*Irreg
+	if old != nil {
+		lemma.translations = old.translations
+		irregs = old.irregs
+		for _, irr := range irregs {
+			irr.Lemma = lemma
+		}
+		l.removeLemma(old)
+	}
+	l.addLemma(lemma)
+	for _, irr := range irregs {
+		l.addIrreg(irr)
+	}
+	return nil
+}
+
+// overlayIrreg applies an overlay directive to irregular forms: data is
+// a line of irregs.la, or "form:lemma" to delete the irregular forms so
+// spelled of the lemma. Overriding replaces them.
+func (l *Lemmatizer) overlayIrreg(action, data string, src source) error {
+	if action == "delete" {
+		form, key, _ := strings.Cut(data, ":")
+		lemma := l.lemmas[Deramise(key)]
+		if lemma == nil || l.removeIrregs(Deramise(Atone(form)), lemma) == 0 {
+			return fmt.Errorf("no irregular form %q of %q to delete", form, key)
+		}
+		return nil
+	}
+
+	irr := l.parseIrreg(data)
+	if irr == nil {
+		return fmt.Errorf("malformed irregular form or unknown lemma in %q", data)
+	}
+	if action == "override" && l.removeIrregs(Deramise(irr.Gr), irr.Lemma) == 0 {
+		return fmt.Errorf("no irregular form %q of %q to override", irr.Gr, irr.Lemma.Key)
+	}
+	irr.src = src
+	l.addIrreg(irr)
+	return nil
+}
+
+// overlayModel applies an overlay directive to the model name, whose
+// lines, as in modeles.la but for the "modele:" line, are body. A model
+// another model inherits from can be neither overridden nor deleted,
+// since its heirs copied its endings; nor can a model some lemmas are
+// inflected on be deleted. The lemmas of an overridden model take the new
+// one, with their radicals derived anew.
+func (l *Lemmatizer) overlayModel(action, name string, body []string, src source) error {
+	old := l.models[name]
+	switch {
+	case action == "add" && old != nil:
+		return fmt.Errorf("model %s already exists", name)
+	case action != "add" && old == nil:
+		return fmt.Errorf("no model %s to %s", name, action)
+	}
+	if old != nil {
+		for _, heir := range slices.Sorted(maps.Keys(l.models)) {
+			if l.models[heir].parent == old {
+				return fmt.Errorf("model %s is the parent of %s", name, heir)
+			}
+		}
+	}
+	if action == "delete" {
+		for _, key := range slices.Sorted(maps.Keys(l.lemmas)) {
+			if l.lemmas[key].model == old {
+				return fmt.Errorf("model %s still inflects %s", name, key)
+			}
+		}
+		l.removeDesinences(old)
+		delete(l.models, name)
+		return nil
+	}
+
+	m := l.parseModel(append([]string{"modele:" + name}, body...))
+	if m.parentName != "" && m.parent == nil {
+		l.removeDesinences(m)
+		return fmt.Errorf("model %s: unknown parent %s", name, m.parentName)
+	}
+	m.src = src
+	l.models[name] = m
+	if old == nil {
+		return nil
+	}
+	l.removeDesinences(old)
+	for _, key := range slices.Sorted(maps.Keys(l.lemmas)) {
+		lemma := l.lemmas[key]
+		if lemma.model != old {
+			continue
+		}
+		l.removeRadicals(lemma)
+		for rn, rads := range lemma.radicals {
+			lemma.radicals[rn] = slices.DeleteFunc(rads, func(r *Radical) bool { return r.derived })
+			if len(lemma.radicals[rn]) == 0 {
+				delete(lemma.radicals, rn)
+			}
+		}
+		l.addLemma(lemma)
+	}
+	return nil
+}
+
+// removeLemma unregisters lemma with its radicals and irregular forms.
+func (l *Lemmatizer) removeLemma(lemma *Lemma) {
+	delete(l.lemmas, lemma.Key)
+	l.removeRadicals(lemma)
+	for _, irr := range lemma.irregs {
+		key := Deramise(irr.Gr)
+		l.irregs[key] = slices.DeleteFunc(l.irregs[key], func(i *Irreg) bool { return i == irr })
+		if len(l.irregs[key]) == 0 {
+			delete(l.irregs, key)
+		}
+	}
+}
+
+// removeRadicals unregisters the radicals of lemma from l.radicals; the
+// lemma keeps them.
+func (l *Lemmatizer) removeRadicals(lemma *Lemma) {
+	for _, rads := range lemma.radicals {
+		for _, r := range rads {
+			key := Deramise(r.Gr)
+			l.radicals[key] = slices.DeleteFunc(l.radicals[key], func(x *Radical) bool { return x.Lemma == lemma })
+			if len(l.radicals[key]) == 0 {
+				delete(l.radicals, key)
+			}
+		}
+	}
+}
+
+// removeIrregs unregisters the irregular forms of lemma whose deramised
+// atone spelling is form, and returns how many there were.
+func (l *Lemmatizer) removeIrregs(form string, lemma *Lemma) int {
+	n := len(l.irregs[form])
+	l.irregs[form] = slices.DeleteFunc(l.irregs[form], func(irr *Irreg) bool { return irr.Lemma == lemma })
+	n -= len(l.irregs[form])
+	if len(l.irregs[form]) == 0 {
+		delete(l.irregs, form)
+	}
+
+	lemma.irregs = slices.DeleteFunc(lemma.irregs, func(irr *Irreg) bool { return Deramise(irr.Gr) == form })
+	lemma.morphosIrregExcl = nil
+	for _, irr := range lemma.irregs {
+		if irr.Exclusive {
+			lemma.morphosIrregExcl = append(lemma.morphosIrregExcl, irr.Morphos...)
+		}
+	}
+	return n
+}
+
+// removeDesinences unregisters the desinences of m from l.desinences.
+func (l *Lemmatizer) removeDesinences(m *Model) {
+	for key, ds := range l.desinences {
+		l.desinences[key] = slices.DeleteFunc(ds, func(d *Desinence) bool { return d.Model == m })
+		if len(l.desinences[key]) == 0 {
+			delete(l.desinences, key)
+		}
+	}
+}
+
+// refreshAfterOverlay rebuilds what New derives from the whole data: the
+// cross-references, the prefix index of Complete, the fingerprint, and the
+// form index when it was built.
+func (l *Lemmatizer) refreshAfterOverlay() {
+	for _, lemma := range l.lemmas {
+		lemma.refsOut, lemma.refsIn = nil, nil
+	}
+	l.linkReferences()
+	l.prefixes = l.buildPrefixIndex()
+	l.sortedLemmas = l.sortLemmas()
+	l.fingerprint = l.computeFingerprint()
+	if l.index != nil {
+		l.index = l.buildFormIndex()
+	}
+	l.tables.Clear()
+}
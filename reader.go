@@ -0,0 +1,74 @@
+package collatinus
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// readerChunk is about how many bytes of whole lines lemmatizeReader
+// reads before tokenizing again.
+const readerChunk = 64 << 10
+
+// lemmatizeReader implements LemmatizeReader. The input is tokenized by
+// chunks of whole lines; the last word of a chunk, which may go on in
+// the next one (rejoined across a line break), is kept for it, with
+// whether it starts a sentence. Each chunk reads readerChunk bytes past
+// what is kept, so that a kept tail of that size or more, as after a word
+// followed by a long run of numbers, still makes progress.
+func (l *Lemmatizer) lemmatizeReader(r io.Reader, fn func(LemmatizationResult) error) error {
+	br := bufio.NewReader(r)
+	opts := TokenizeOptions{JoinHyphenated: l.opts.JoinHyphenated}
+	// base is the offset of carry in the composed input; start tells
+	// whether the next word starts a sentence, as Tokenize has it.
+	carry, base, start := "", 0, true
+	for eof := false; !eof; {
+		var b strings.Builder
+		b.WriteString(carry)
+		for b.Len() < len(carry)+readerChunk && !eof {
+			line, err := br.ReadString('\n')
+			b.WriteString(line)
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		text := norm.NFC.String(b.String())
+		tokens := TokenizeWithOptions(text, opts)
+		inscription := l.opts.Inscriptions && mostlyCapitals(tokens)
+
+		end := len(tokens)
+		if !eof {
+			for k := len(tokens) - 1; k >= 0; k-- {
+				if tokens[k].IsWord {
+					end = k
+					break
+				}
+			}
+		}
+		for _, t := range tokens[:end] {
+			if !t.IsWord {
+				start = start || strings.ContainsAny(t.Text, sentenceEnds)
+				continue
+			}
+			t.SentenceStart, start = start, false
+			res := l.lemmatizeToken(t, inscription)
+			res.Start += base
+			res.End += base
+			if err := fn(res); err != nil {
+				return err
+			}
+		}
+		carry = ""
+		if end < len(tokens) {
+			carry = text[tokens[end].Start:]
+			base += tokens[end].Start
+		} else {
+			base += len(text)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package collatinus
+
+// reload implements Reload: the data is loaded outside the lock, which
+// is only held to swap it in.
+func (l *Lemmatizer) reload(dataDir string) error {
+	fresh, err := load(dataDir, false, l.config)
+	if err != nil {
+		return err
+	}
+	if l.index != nil {
+		fresh.index = fresh.buildFormIndex()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.morphos, l.morphologies = fresh.morphos, fresh.morphologies
+	l.models, l.lemmas = fresh.models, fresh.lemmas
+	l.desinences, l.radicals, l.irregs = fresh.desinences, fresh.radicals, fresh.irregs
+	l.variables, l.languages = fresh.variables, fresh.languages
+	l.assims, l.contractions = fresh.assims, fresh.contractions
+	l.fingerprint, l.warnings = fresh.fingerprint, fresh.warnings
+	l.prefixes, l.sortedLemmas, l.index = fresh.prefixes, fresh.sortedLemmas, fresh.index
+	l.tables.Clear()
+	return nil
+}
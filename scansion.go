@@ -0,0 +1,572 @@
+package collatinus
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FootKind is the kind of a metrical foot.
+type FootKind int
+
+const (
+	Dactyl  FootKind = iota // long, short, short
+	Spondee                 // long, long
+	Trochee                 // long, short: the sixth foot only
+)
+
+// String returns the English name of the foot kind.
+func (k FootKind) String() string {
+	switch k {
+	case Spondee:
+		return "spondee"
+	case Trochee:
+		return "trochee"
+	default:
+		return "dactyl"
+	}
+}
+
+// Foot is one foot of a scanned line; see ScanLine.
+type Foot struct {
+	Kind      FootKind
+	Syllables []Syllable
+}
+
+// Syllable is a syllable of a scanned line or word; see ScanLine and
+// Scan.
+type Syllable struct {
+	// Text is the syllable spelled with the quantity marks of the
+	// lexicon. A syllable elided before a vowel is kept in front of the
+	// next one, joined with "‿": "rĕ‿ōm".
+	Text string
+	// Long is the length the syllable takes in its foot; for Scan, it is
+	// set when the vowel is long or the syllable long by position.
+	Long bool
+	// WordEnd is set for the last syllable of a word.
+	WordEnd bool
+	// Vowel is the vowel of the syllable, both letters of a diphthong;
+	// set by Scan only.
+	Vowel string
+	// Quantity is the length of Vowel; set by Scan only.
+	Quantity Quantity
+	// ByPosition is set when two consonants follow Vowel, making the
+	// syllable long whatever its length; set by Scan only.
+	ByPosition bool
+}
+
+// Quantity is the length of a vowel or of a syllable.
+type Quantity int
+
+const (
+	// QuantityCommon: unmarked, marked both ways (ō̆), or short before a
+	// mute and a liquid, which may be read either way.
+	QuantityCommon Quantity = iota
+	QuantityShort
+	QuantityLong
+)
+
+// String returns "common", "short" or "long".
+func (q Quantity) String() string {
+	switch q {
+	case QuantityShort:
+		return "short"
+	case QuantityLong:
+		return "long"
+	default:
+		return "common"
+	}
+}
+
+// ScanOptions tunes ScanLineWithOptions.
+type ScanOptions struct {
+	// ElideFinalM elides a final vowel followed by m before a word
+	// starting with a vowel or h ("multum ille" → "mult‿ille"), as a final
+	// vowel is. Otherwise the m closes the syllable like any consonant.
+	ElideFinalM bool
+}
+
+const (
+	macronVowels = "āēīōūȳǣ"
+	breveVowels  = "ăĕĭŏŭў"
+)
+
+// maxScanCombinations bounds the spellings of a line scanLine tries,
+// which multiply with each word read several ways.
+const maxScanCombinations = 512
+
+// scanLetter is a letter of a word to scan.
+type scanLetter struct {
+	// text is the letter as spelled, with its marks.
+	text string
+	// base is the bare lowercase letter; j and v are consonants.
+	base rune
+	// vowel is set for the vowel of a syllable, and glide for the second
+	// vowel of a diphthong, which belongs to the syllable of the first.
+	vowel, glide bool
+	q            Quantity
+	// weight is what a consonant counts for length by position: 2 for x
+	// and z, 0 for h and the u of qu.
+	weight int
+}
+
+// parseScanWord splits the spelling s of a word into letters. marked
+// tells that s comes from the lexicon, whose consonantal i and u are
+// spelled j and v; otherwise bare i and u before a vowel at the start of
+// the word or between vowels are read as consonants. The vowels of
+// short, an enclitic, are short.
+func parseScanWord(s string, marked, short bool) []scanLetter {
+	var out []scanLetter
+	for _, r := range strings.ToLower(s) {
+		if r == '\u0306' {
+			if len(out) > 0 {
+				out[len(out)-1].text += string(r)
+				out[len(out)-1].q = QuantityCommon
+			}
+			continue
+		}
+		c := scanLetter{text: string(r), weight: 1}
+		base := []rune(Atone(string(r)))
+		c.base = base[0]
+		switch {
+		case strings.ContainsRune(macronVowels, r):
+			c.q = QuantityLong
+		case strings.ContainsRune(breveVowels, r):
+			c.q = QuantityShort
+		}
+		switch c.base {
+		case 'a', 'e', 'i', 'o', 'u', 'y':
+			c.vowel, c.weight = true, 0
+		case 'æ', 'œ':
+			c.vowel, c.weight, c.q = true, 0, QuantityLong
+		case 'x', 'z':
+			c.weight = 2
+		case 'h', 'ụ':
+			// Atone keeps the dot of the silent u of the lexicon
+			// (sụāvĭs, lĭngụă), which counts for nothing.
+			c.weight = 0
+		}
+		out = append(out, c)
+	}
+
+	bare := func(c scanLetter) bool { return c.vowel && c.q == QuantityCommon && len(c.text) == len(string(c.base)) }
+	for i := range out {
+		c := &out[i]
+		if !bare(*c) || (c.base != 'i' && c.base != 'u') {
+			continue
+		}
+		prevVowel := i > 0 && out[i-1].vowel
+		nextVowel := i+1 < len(out) && out[i+1].vowel
+		switch {
+		case c.base == 'u' && i > 0 && out[i-1].base == 'q':
+			c.vowel = false
+		case !marked && nextVowel && (i == 0 || prevVowel):
+			c.vowel, c.weight = false, 1
+		}
+	}
+	for i := 1; i < len(out); i++ {
+		first, c := &out[i-1], &out[i]
+		if !first.vowel || first.glide || first.q == QuantityShort || !bare(*c) {
+			continue
+		}
+		switch string([]rune{first.base, c.base}) {
+		case "ae", "oe", "au":
+		case "eu":
+			if !marked {
+				continue
+			}
+		default:
+			continue
+		}
+		first.q, c.glide = QuantityLong, true
+	}
+	if short {
+		for i := range out {
+			if out[i].vowel && !out[i].glide {
+				out[i].q = QuantityShort
+			}
+		}
+	}
+	return out
+}
+
+// scanSyllables splits the letters of a word into syllables, each one
+// holding its vowel: a consonant between two vowels starts the second
+// syllable, and of two or more the last one does, or the last two for a
+// mute followed by a liquid or by h.
+func scanSyllables(letters []scanLetter) [][]scanLetter {
+	var nuclei []int
+	for i, c := range letters {
+		if c.vowel && !c.glide {
+			nuclei = append(nuclei, i)
+		}
+	}
+	if len(nuclei) == 0 {
+		return nil
+	}
+	starts := []int{0}
+	for k := 1; k < len(nuclei); k++ {
+		end := nuclei[k-1] + 1
+		for end < nuclei[k] && letters[end].glide {
+			end++
+		}
+		start := nuclei[k]
+		if n := nuclei[k] - end; n == 1 {
+			start--
+		} else if n >= 2 {
+			start--
+			last := letters[start]
+			digraph := last.weight == 0 && !last.vowel
+			muteLiquid := strings.ContainsRune("pbtdcgf", letters[start-1].base) && strings.ContainsRune("lr", last.base)
+			if digraph || muteLiquid {
+				start--
+			}
+		}
+		starts = append(starts, start)
+	}
+	syllables := make([][]scanLetter, len(starts))
+	for k, start := range starts {
+		end := len(letters)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		syllables[k] = letters[start:end]
+	}
+	return syllables
+}
+
+// Scan splits formWithMarks, a form spelled with the quantity marks of
+// the lexicon as in FormWithMarks, into its syllables, with the vowel of
+// each, its quantity and whether it is long by position. The diphthongs
+// ae, oe and au make one long syllable; a short vowel before a mute and
+// a liquid is common. A form without vowels has no syllables.
+func Scan(formWithMarks string) []Syllable {
+	letters := parseScanWord(formWithMarks, true, false)
+	sylls := scanSyllables(letters)
+	out := make([]Syllable, 0, len(sylls))
+	for i, c := range letters {
+		if !c.vowel || c.glide {
+			continue
+		}
+		syll := Syllable{Vowel: c.text, Quantity: c.q, WordEnd: len(out) == len(sylls)-1}
+		for _, d := range sylls[len(out)] {
+			syll.Text += d.text
+		}
+		j := i + 1
+		for ; j < len(letters) && letters[j].glide; j++ {
+			syll.Vowel += letters[j].text
+		}
+		var cs []scanLetter
+		weight := 0
+		for ; j < len(letters) && !letters[j].vowel; j++ {
+			cs = append(cs, letters[j])
+			weight += letters[j].weight
+		}
+		muteLiquid := j < len(letters) && len(cs) == 2 && weight == 2 &&
+			strings.ContainsRune("pbtdcgf", cs[0].base) && strings.ContainsRune("lr", cs[1].base)
+		switch {
+		case muteLiquid:
+			if syll.Quantity == QuantityShort {
+				syll.Quantity = QuantityCommon
+			}
+		case weight >= 2:
+			syll.ByPosition = true
+		}
+		syll.Long = syll.Quantity == QuantityLong || syll.ByPosition
+		out = append(out, syll)
+	}
+	return out
+}
+
+// scanCandidates returns the spellings word may be scanned with: those
+// of its analyses that spell it, with an enclitic the analyses lack, or
+// else word itself. The spellings of the same letters are merged, a
+// vowel whose quantity differs becoming common.
+func (l *Lemmatizer) scanCandidates(word string, sentenceStart bool) [][]scanLetter {
+	key := Deramise(Atone(strings.ToLower(word)))
+	var forms []string
+	for _, analyses := range l.lemmatizeM(word, sentenceStart) {
+		for _, a := range analyses {
+			if !slices.Contains(forms, a.FormWithMarks) {
+				forms = append(forms, a.FormWithMarks)
+			}
+		}
+	}
+	sort.Strings(forms)
+
+	var out [][]scanLetter
+	for _, f := range forms {
+		fk := Deramise(Atone(strings.ToLower(f)))
+		if !strings.HasPrefix(key, fk) {
+			continue
+		}
+		letters := parseScanWord(f, true, false)
+		if rest := key[len(fk):]; rest != "" {
+			if !slices.Contains(enclitics, rest) {
+				continue
+			}
+			letters = append(letters, parseScanWord(rest, false, true)...)
+		}
+		merged := false
+		for _, o := range out {
+			if sameScanLetters(o, letters) {
+				for i := range o {
+					if o[i].q != letters[i].q {
+						o[i].q = QuantityCommon
+					}
+				}
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			out = append(out, letters)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, parseScanWord(word, false, false))
+	}
+	return out
+}
+
+// sameScanLetters tells whether a and b spell the same letters with the
+// same vowels and diphthongs.
+func sameScanLetters(a, b []scanLetter) bool {
+	return slices.EqualFunc(a, b, func(x, y scanLetter) bool {
+		return x.base == y.base && x.vowel == y.vowel && x.glide == y.glide
+	})
+}
+
+// scannedSyllable is a syllable of a line, with its length and whether
+// it ends a word.
+type scannedSyllable struct {
+	text    string
+	q       Quantity
+	wordEnd bool
+}
+
+// lineSyllables returns the syllables of the words of a line but the
+// elided ones, whose text goes in front of the next syllable, with
+// their lengths. The last syllable of the line has the length of its
+// vowel, as nothing follows.
+func lineSyllables(words [][]scanLetter, opts ScanOptions) []scannedSyllable {
+	// flat holds the letters of the line, each with its word and
+	// syllable; the vowel of an elided syllable, and what follows it,
+	// are dropped, but its onset is kept.
+	type lineLetter struct {
+		scanLetter
+		word, syll int
+		dropped    bool
+	}
+	var flat []lineLetter
+	var texts []string
+	var elided []bool
+	for w, letters := range words {
+		ss := scanSyllables(letters)
+		for _, s := range ss {
+			var b strings.Builder
+			for _, c := range s {
+				b.WriteString(c.text)
+				flat = append(flat, lineLetter{scanLetter: c, word: w, syll: len(texts)})
+			}
+			texts = append(texts, b.String())
+			elided = append(elided, false)
+		}
+		if len(ss) == 0 || w+1 == len(words) {
+			continue
+		}
+		// Elide the last vowel of the word, with a final m if asked,
+		// before a vowel or h.
+		last := ss[len(ss)-1]
+		tail := last[len(last)-1]
+		ends := tail.vowel || (opts.ElideFinalM && tail.base == 'm' && len(last) >= 2 && last[len(last)-2].vowel)
+		next := words[w+1]
+		if len(next) > 0 && next[0].base == 'h' {
+			next = next[1:]
+		}
+		if !ends || len(next) == 0 || !next[0].vowel {
+			continue
+		}
+		elided[len(elided)-1] = true
+		onset := true
+		for i := len(flat) - len(last); i < len(flat); i++ {
+			onset = onset && !flat[i].vowel
+			flat[i].dropped = !onset
+		}
+	}
+
+	var out []scannedSyllable
+	prefix := ""
+	for i, c := range flat {
+		if !c.vowel || c.glide || c.dropped {
+			continue
+		}
+		if elided[c.syll] {
+			continue
+		}
+		for s := c.syll - 1; s >= 0 && elided[s]; s-- {
+			prefix = texts[s] + "‿" + prefix
+		}
+		// The consonants up to the next vowel make the syllable long by
+		// position, but for a mute and a liquid within a word, after
+		// which a short vowel may be read either way.
+		q := c.q
+		var cs []lineLetter
+		j := i + 1
+		for ; j < len(flat); j++ {
+			if flat[j].dropped || flat[j].glide {
+				continue
+			}
+			if flat[j].vowel {
+				break
+			}
+			cs = append(cs, flat[j])
+		}
+		weight := 0
+		for _, d := range cs {
+			weight += d.weight
+		}
+		if j < len(flat) && q != QuantityLong {
+			switch {
+			case len(cs) == 2 && weight == 2 && flat[j].word == c.word && cs[0].word == c.word &&
+				strings.ContainsRune("pbtdcgf", cs[0].base) && strings.ContainsRune("lr", cs[1].base):
+				q = QuantityCommon
+			case weight >= 2:
+				q = QuantityLong
+			}
+		}
+		wordEnd := true
+		for _, d := range flat[i+1:] {
+			if d.syll != c.syll {
+				wordEnd = d.word != c.word
+				break
+			}
+		}
+		out = append(out, scannedSyllable{prefix + texts[c.syll], q, wordEnd})
+		prefix = ""
+	}
+	return out
+}
+
+// footLengths are the lengths of the syllables of each kind of foot.
+var footLengths = map[FootKind][]bool{
+	Dactyl:  {true, false, false},
+	Spondee: {true, true},
+	Trochee: {true, false},
+}
+
+// fitHexameter fits the lengths qs to a dactylic hexameter: four dactyls
+// or spondees, a dactyl (or, at a cost, a spondee) and a long syllable
+// followed by any. It returns the kinds of the feet of the best fit, the
+// cost of the fit and whether there is one.
+func fitHexameter(qs []Quantity) ([]FootKind, int, bool) {
+	long := func(i int) bool { return i < len(qs) && qs[i] != QuantityShort }
+	short := func(i int) bool { return i < len(qs) && qs[i] != QuantityLong }
+	var best []FootKind
+	bestCost := -1
+	feet := make([]FootKind, 0, 6)
+	var fit func(pos, cost int)
+	fit = func(pos, cost int) {
+		if bestCost >= 0 && cost >= bestCost {
+			return
+		}
+		if len(feet) == 5 {
+			if pos+2 == len(qs) && long(pos) {
+				kind := Spondee
+				if qs[pos+1] == QuantityShort {
+					kind = Trochee
+				}
+				best, bestCost = append(slices.Clone(feet), kind), cost
+			}
+			return
+		}
+		if long(pos) && short(pos+1) && short(pos+2) {
+			feet = append(feet, Dactyl)
+			fit(pos+3, cost)
+			feet = feet[:len(feet)-1]
+		}
+		if long(pos) && long(pos+1) {
+			extra := 0
+			if len(feet) == 4 {
+				extra = 1
+			}
+			feet = append(feet, Spondee)
+			fit(pos+2, cost+extra)
+			feet = feet[:len(feet)-1]
+		}
+	}
+	fit(0, 0)
+	return best, bestCost, bestCost >= 0
+}
+
+// scanLine implements ScanLineWithOptions.
+func (l *Lemmatizer) scanLine(line string, opts ScanOptions) []Foot {
+	var cands [][][]scanLetter
+	for _, t := range Tokenize(line) {
+		if t.IsWord {
+			cands = append(cands, l.scanCandidates(t.Text, t.SentenceStart))
+		}
+	}
+	if len(cands) == 0 {
+		return nil
+	}
+
+	var best []Foot
+	bestCost := -1
+	choice := make([]int, len(cands))
+	words := make([][]scanLetter, len(cands))
+	for n := 0; n < maxScanCombinations; n++ {
+		for i, c := range choice {
+			words[i] = cands[i][c]
+		}
+		sylls := lineSyllables(words, opts)
+		qs := make([]Quantity, len(sylls))
+		for i, s := range sylls {
+			qs[i] = s.q
+		}
+		if kinds, cost, ok := fitHexameter(qs); ok && (bestCost < 0 || cost < bestCost) {
+			best, bestCost = nil, cost
+			pos := 0
+			for _, k := range kinds {
+				lengths := footLengths[k]
+				foot := Foot{Kind: k}
+				for i, s := range sylls[pos : pos+len(lengths)] {
+					foot.Syllables = append(foot.Syllables, Syllable{Text: s.text, Long: lengths[i], WordEnd: s.wordEnd})
+				}
+				best = append(best, foot)
+				pos += len(lengths)
+			}
+		}
+		// next combination of spellings, the last word varying fastest
+		i := len(choice) - 1
+		for ; i >= 0; i-- {
+			if choice[i]++; choice[i] < len(cands[i]) {
+				break
+			}
+			choice[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+	return best
+}
+
+// Caesura names the main caesura of the hexameter feet, the word end
+// within a foot: "penthemimeral" after the long of the third foot,
+// "feminine" after its first short, "hephthemimeral" after the long of
+// the fourth foot, or "" for none. An elided syllable ends no word.
+func Caesura(feet []Foot) string {
+	ends := func(foot, syll int) bool {
+		return foot < len(feet) && syll < len(feet[foot].Syllables) && feet[foot].Syllables[syll].WordEnd
+	}
+	switch {
+	case ends(2, 0):
+		return "penthemimeral"
+	case ends(2, 1) && feet[2].Kind == Dactyl:
+		return "feminine"
+	case ends(3, 0):
+		return "hephthemimeral"
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package collatinus
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// AnalysisScore holds the signals by which an analysis of a form may be
+// preferred to the others, for callers to weigh as they see fit; see
+// Scores.
+type AnalysisScore struct {
+	// FrequencyRank is the number of lemmas of the form more frequent
+	// (by NbOcc) than that of the analysis: 0 for the most frequent.
+	FrequencyRank int
+	// Derivation, StemLength and Attested are those of the analysis.
+	Derivation Source
+	StemLength int
+	Attested   bool
+	// PreferredRegister is set when the lemma is of one of the
+	// PreferRegisters of the options.
+	PreferredRegister bool
+}
+
+// Score combines the signals of s into one number, the higher the
+// likelier: the preferred registers first, then frequency, then the
+// fewest rewritings, then the longest stem. It is the default weighing,
+// which callers may replace with their own.
+func (s AnalysisScore) Score() int {
+	score := s.StemLength - 100*bits.OnesCount8(uint8(s.Derivation)) - 1000*s.FrequencyRank
+	if s.PreferredRegister {
+		score += 1_000_000
+	}
+	return score
+}
+
+// RankedAnalysis is a lemma of a form with its analyses, as ranked by
+// LemmatizeWordRanked.
+type RankedAnalysis struct {
+	Lemma    *Lemma
+	Analyses []Analysis
+	// Score is the NbOcc of the lemma, halved when the form only reaches
+	// it by a rewriting (an enclitic stripped, say) and halved again when
+	// only as a proper noun, by capitalizing a lower-case form.
+	Score float64
+}
+
+// scores implements Scores.
+func (l *Lemmatizer) scores(mm map[*Lemma][]Analysis) map[*Lemma][]AnalysisScore {
+	out := make(map[*Lemma][]AnalysisScore, len(mm))
+	for lemma, analyses := range mm {
+		rank := 0
+		for other := range mm {
+			if other.NbOcc > lemma.NbOcc {
+				rank++
+			}
+		}
+		ss := make([]AnalysisScore, len(analyses))
+		for i, a := range analyses {
+			ss[i] = analysisScore(a)
+			ss[i].FrequencyRank = rank
+			ss[i].PreferredRegister = l.prefersRegister(lemma)
+		}
+		out[lemma] = ss
+	}
+	return out
+}
+
+// analysisScore returns the AnalysisScore of a with a FrequencyRank of 0,
+// for comparing the analyses of one lemma.
+func analysisScore(a Analysis) AnalysisScore {
+	return AnalysisScore{Derivation: a.Derivation, StemLength: a.StemLength, Attested: a.Attested}
+}
+
+// prefersRegister reports whether the register of lemma is one of the
+// PreferRegisters of the options.
+func (l *Lemmatizer) prefersRegister(lemma *Lemma) bool {
+	return slices.Contains(l.opts.PreferRegisters, lemma.register)
+}
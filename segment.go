@@ -0,0 +1,110 @@
+package collatinus
+
+import (
+	"golang.org/x/text/unicode/norm"
+)
+
+// Segmentation is one way of cutting a form into a stem and an ending for
+// which both a radical and a desinence exist, before the model and
+// radical-number consistency checks of the lemmatizer.
+type Segmentation struct {
+	// Stem is the (deramised) beginning of the form.
+	Stem string
+	// Ending is the (deramised) rest of the form; it may be empty.
+	Ending string
+	// Radicals lists every radical whose Gr equals Stem.
+	Radicals []*Radical
+	// Desinences lists every desinence whose Gr equals Ending.
+	Desinences []*Desinence
+}
+
+const (
+	// maxSegmentRunes bounds the length of the words segmentContinuous
+	// looks for.
+	maxSegmentRunes = 30
+	// unknownRuneCost is what a letter no word covers costs a
+	// segmentation, against 1 for each word: a letter is left unknown
+	// rather than cutting three words out of it and its neighbours.
+	unknownRuneCost = 2
+)
+
+// segmentContinuous implements SegmentContinuous.
+func (l *Lemmatizer) segmentContinuous(text string) []LemmatizationResult {
+	text = norm.NFC.String(text)
+	var results []LemmatizationResult
+	for _, t := range Tokenize(text) {
+		if !t.IsWord {
+			continue
+		}
+		caps := isCapitals(t.Text)
+		read := []rune(t.Text)
+		if caps {
+			read = []rune(capitalsForm(t.Text))
+		}
+		// offsets[i] is the byte offset in text of the rune i of the word.
+		offsets := make([]int, 0, len(read)+1)
+		for i := range t.Text {
+			offsets = append(offsets, t.Start+i)
+		}
+		offsets = append(offsets, t.End)
+		if len(offsets) != len(read)+1 {
+			// lower-casing changed the length: keep the word whole
+			read = []rune(t.Text)
+			caps = false
+		}
+
+		for _, span := range l.segmentWord(read) {
+			form, sentenceStart := string(read[span[0]:span[1]]), t.SentenceStart && span[0] == 0
+			var analyses map[*Lemma][]Analysis
+			if caps {
+				analyses = l.lemmatizeCapitals(form)
+			} else {
+				analyses = l.lemmatizeM(form, sentenceStart)
+			}
+			results = append(results, LemmatizationResult{
+				Token:    text[offsets[span[0]]:offsets[span[1]]],
+				Analyses: analyses,
+				Start:    offsets[span[0]],
+				End:      offsets[span[1]],
+
+				SentenceStart: t.SentenceStart && span[0] == 0,
+			})
+		}
+	}
+	return results
+}
+
+// segmentWord cuts word into the spans of runes of the cheapest
+// segmentation: each word costs 1 and each letter left out of the words
+// unknownRuneCost, the runs of such letters making spans of their own.
+// Among segmentations of equal cost, the longest first word wins.
+func (l *Lemmatizer) segmentWord(word []rune) [][2]int {
+	n := len(word)
+	// cost[i] is the cost of the best segmentation of word[i:], whose
+	// first span ends at next[i]; known[i] tells whether it is a word.
+	cost := make([]int, n+1)
+	next := make([]int, n+1)
+	known := make([]bool, n+1)
+	for i := n - 1; i >= 0; i-- {
+		cost[i], next[i] = unknownRuneCost+cost[i+1], i+1
+		for j := min(n, i+maxSegmentRunes); j > i; j-- {
+			if 1+cost[j] < cost[i] || 1+cost[j] == cost[i] && !known[i] {
+				if l.isValidForm(string(word[i:j])) {
+					cost[i], next[i], known[i] = 1+cost[j], j, true
+				}
+			}
+		}
+	}
+
+	var spans [][2]int
+	for i := 0; i < n; i = next[i] {
+		if !known[i] && len(spans) > 0 {
+			if last := spans[len(spans)-1]; !known[last[0]] {
+				spans[len(spans)-1][1] = next[i]
+				continue
+			}
+		}
+		spans = append(spans, [2]int{i, next[i]})
+	}
+	return spans
+}
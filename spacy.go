@@ -0,0 +1,113 @@
+package collatinus
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SpacyDoc is a lemmatized text in the JSON layout of spaCy's
+// Doc.to_json, which Doc.from_json reads back; see Lemmatizer.SpacyDoc.
+type SpacyDoc struct {
+	// Text is the text, composed to NFC.
+	Text string `json:"text"`
+	// Tokens covers the whole text: words, punctuation and the
+	// whitespace that is not a single space after a token.
+	Tokens []SpacyToken `json:"tokens"`
+}
+
+// SpacyToken is one token of a SpacyDoc.
+type SpacyToken struct {
+	// ID is the 0-based position of the token.
+	ID int `json:"id"`
+	// Start and End are the character (code point) offsets of the token.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// POS is the Universal Dependencies tag (NOUN, VERB, PUNCT, X…).
+	POS string `json:"pos"`
+	// Morph holds the UD features, e.g. "Case=Gen|Number=Sing".
+	Morph string `json:"morph"`
+	// Lemma is the unmarked canonical form, or the token itself when
+	// it is unknown.
+	Lemma string `json:"lemma"`
+}
+
+// spacyDoc implements SpacyDoc.
+func (l *Lemmatizer) spacyDoc(text string) SpacyDoc {
+	text = norm.NFC.String(text)
+	doc := SpacyDoc{Text: text, Tokens: []SpacyToken{}}
+	pos, rpos := 0, 0 // byte and rune offsets of the text left to cover
+	add := func(end int, tag, morph, lemma string) {
+		rend := rpos + utf8.RuneCountInString(text[pos:end])
+		doc.Tokens = append(doc.Tokens, SpacyToken{
+			ID:    len(doc.Tokens),
+			Start: rpos,
+			End:   rend,
+			POS:   tag,
+			Morph: morph,
+			Lemma: lemma,
+		})
+		pos, rpos = end, rend
+	}
+	skip := func(end int) {
+		rpos += utf8.RuneCountInString(text[pos:end])
+		pos = end
+	}
+	// gap covers text[pos:end], which holds no word: one token per run of
+	// punctuation, and one SPACE token per run of whitespace, except for
+	// a single space following a token, which spaCy keeps on the token.
+	gap := func(end int) {
+		for pos < end {
+			r, _ := utf8.DecodeRuneInString(text[pos:])
+			if r == ' ' && len(doc.Tokens) > 0 && doc.Tokens[len(doc.Tokens)-1].End == rpos {
+				skip(pos + 1)
+				continue
+			}
+			space := unicode.IsSpace(r)
+			run := pos
+			for run < end {
+				r, size := utf8.DecodeRuneInString(text[run:])
+				if unicode.IsSpace(r) != space {
+					break
+				}
+				run += size
+			}
+			tok := text[pos:run]
+			switch {
+			case space:
+				add(run, "SPACE", "", tok)
+			case strings.IndexFunc(tok, func(r rune) bool { return !unicode.IsDigit(r) }) < 0:
+				add(run, "NUM", "", tok)
+			case strings.IndexFunc(tok, unicode.IsLetter) < 0:
+				add(run, "PUNCT", "", tok)
+			default:
+				add(run, "X", "", tok)
+			}
+		}
+	}
+
+	for _, res := range l.disambiguateText(text) {
+		gap(res.Start)
+		lemma, analysis := l.bestAnalysis(res.Analyses)
+		if lemma == nil {
+			add(res.End, "X", "", res.Token)
+			continue
+		}
+		add(res.End, UDTag(lemma), udFeats(l.MorphoOf(lemma, analysis.MorphoIndex)), lemma.Gr)
+	}
+	gap(len(text))
+	return doc
+}
+
+// bestAnalysis returns the first analysis of the first lemma of mm in
+// rankLemmas order, or a nil lemma when mm is empty.
+func (l *Lemmatizer) bestAnalysis(mm map[*Lemma][]Analysis) (*Lemma, Analysis) {
+	for _, lemma := range l.rankLemmas(mm) {
+		if len(mm[lemma]) > 0 {
+			return lemma, mm[lemma][0]
+		}
+	}
+	return nil, Analysis{}
+}
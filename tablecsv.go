@@ -0,0 +1,27 @@
+package collatinus
+
+import (
+	"encoding/csv"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// exportInflectionCSV implements ExportInflectionCSV.
+func (l *Lemmatizer) exportInflectionCSV(table *InflectionTable, w io.Writer) error {
+	if table == nil {
+		table = &InflectionTable{}
+	}
+	cw := csv.NewWriter(w)
+	if table.Lemma != nil {
+		cw.Write([]string{"lemma", table.Lemma.Key, table.Lemma.Grq})
+	}
+	cw.Write([]string{"index", "morpho", "forms"})
+	for _, mn := range slices.Sorted(maps.Keys(table.Cells)) {
+		cw.Write([]string{strconv.Itoa(mn), l.MorphoOf(table.Lemma, mn), strings.Join(table.Cells[mn], ", ")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
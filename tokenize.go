@@ -0,0 +1,87 @@
+package collatinus
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Token is one token of a text; see Tokenize.
+type Token struct {
+	// Text is the token as it appears in the text, but for a word
+	// rejoined across a line break, which lacks the hyphen and the break.
+	Text string
+	// Start and End are the byte offsets of Text in the text, once
+	// composed to NFC (the text itself when it already is).
+	Start, End int
+	// IsWord is false for punctuation, digits and other non-letters.
+	IsWord bool
+	// SentenceStart is true for a word that starts a sentence.
+	SentenceStart bool
+}
+
+// TokenizeOptions tunes TokenizeWithOptions.
+type TokenizeOptions struct {
+	// JoinHyphenated rejoins a word broken across lines with a hyphen
+	// ("pu-\nella") into one token, puella, spanning both halves.
+	JoinHyphenated bool
+}
+
+// sentenceEnds are the punctuation marks after which a word starts a
+// sentence.
+const sentenceEnds = ".!?;:"
+
+// lineBreakHyphen matches what separates the two halves of a word broken
+// across lines: a hyphen (or soft hyphen) ending a line.
+var lineBreakHyphen = regexp.MustCompile(`^[-\x{00AD}\x{2010}][ \t]*\r?\n\s*$`)
+
+// Tokenize splits text, composed to NFC, into its words and its runs of
+// other non-space characters (punctuation, digits), in order. Whitespace
+// separates tokens and is not itself a token. A word starts a sentence
+// when it is the first one or when the tokens since the previous word
+// hold one of . ! ? ; or :.
+func Tokenize(text string) []Token {
+	return TokenizeWithOptions(text, TokenizeOptions{})
+}
+
+// TokenizeWithOptions is Tokenize with the options of opts.
+func TokenizeWithOptions(text string, opts TokenizeOptions) []Token {
+	// Compose decomposed input ("a" + U+0304 → "ā") so that no combining
+	// mark is left alone at a token boundary.
+	text = norm.NFC.String(text)
+	var tokens []Token
+	pos, start := 0, true
+	// gap adds the non-space runs of text[pos:end], which holds no word.
+	gap := func(end int) {
+		for _, f := range strings.FieldsFunc(text[pos:end], unicode.IsSpace) {
+			i := pos + strings.Index(text[pos:end], f)
+			tokens = append(tokens, Token{Text: f, Start: i, End: i + len(f)})
+			pos = i + len(f)
+			start = start || strings.ContainsAny(f, sentenceEnds)
+		}
+		pos = end
+	}
+	locs := reWord.FindAllStringIndex(text, -1)
+	for i := 0; i < len(locs); i++ {
+		loc := locs[i]
+		gap(loc[0])
+		tok := Token{
+			Text:          text[loc[0]:loc[1]],
+			Start:         loc[0],
+			End:           loc[1],
+			IsWord:        true,
+			SentenceStart: start,
+		}
+		if opts.JoinHyphenated && i+1 < len(locs) && lineBreakHyphen.MatchString(text[loc[1]:locs[i+1][0]]) {
+			i++
+			tok.Text += text[locs[i][0]:locs[i][1]]
+			tok.End = locs[i][1]
+		}
+		tokens = append(tokens, tok)
+		pos, start = tok.End, false
+	}
+	gap(len(text))
+	return tokens
+}
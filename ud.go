@@ -0,0 +1,250 @@
+package collatinus
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MorphoFeatures is what all the analyses of a form agree on; see
+// CertainFeatures.
+type MorphoFeatures struct {
+	// Lemma is the lemma of every analysis, or nil when there are several.
+	Lemma *Lemma
+	// Feats maps the name of each Universal Dependencies feature all the
+	// analyses share to its value: "Case" → "Acc".
+	Feats map[string]string
+}
+
+// MoodMatch is an imperative or infinitive analysis of a verb form; see
+// VerbMood.
+type MoodMatch struct {
+	Lemma *Lemma
+	// Mood is "Imp" for an imperative and "Inf" for an infinitive.
+	Mood string
+	// Person (2 or 3) and Number ("Sing" or "Plur") are those of an
+	// imperative, and zero for an infinitive.
+	Person int
+	Number string
+	// MorphoIndex is the full analysis, with tense and voice.
+	MorphoIndex int
+}
+
+// udTags maps parts of speech to Universal Dependencies tags.
+var udTags = map[PartOfSpeech]string{
+	POSNoun:         "NOUN",
+	POSVerb:         "VERB",
+	POSAdjective:    "ADJ",
+	POSPronoun:      "PRON",
+	POSAdverb:       "ADV",
+	POSConjunction:  "CCONJ",
+	POSExclamation:  "INTJ",
+	POSInterjection: "INTJ",
+	POSNumeral:      "NUM",
+	POSPreposition:  "ADP",
+}
+
+// udFeatures maps the words (or two-word phrases) of the French morpho
+// descriptions to Universal Dependencies features, through which
+// parseMorphology reads their Morphology too.
+var udFeatures = map[string][]string{
+	"1ère": {"Person=1"}, "2ème": {"Person=2"}, "3ème": {"Person=3"},
+	"nominatif": {"Case=Nom"}, "vocatif": {"Case=Voc"},
+	"accusatif": {"Case=Acc"}, "génitif": {"Case=Gen"},
+	"datif": {"Case=Dat"}, "ablatif": {"Case=Abl"}, "locatif": {"Case=Loc"},
+	"masculin": {"Gender=Masc"}, "féminin": {"Gender=Fem"}, "neutre": {"Gender=Neut"},
+	"singulier": {"Number=Sing"}, "pluriel": {"Number=Plur"},
+	"indicatif":  {"Mood=Ind", "VerbForm=Fin"},
+	"subjonctif": {"Mood=Sub", "VerbForm=Fin"},
+	"impératif":  {"Mood=Imp", "VerbForm=Fin"},
+	"infinitif":  {"VerbForm=Inf"}, "participe": {"VerbForm=Part"},
+	"gérondif": {"VerbForm=Ger"}, "adjectif verbal": {"VerbForm=Gdv"},
+	"supin":   {"VerbForm=Sup"},
+	"présent": {"Tense=Pres"}, "imparfait": {"Aspect=Imp", "Tense=Past"},
+	"futur": {"Tense=Fut"}, "parfait": {"Aspect=Perf", "Tense=Past"},
+	"PQP":              {"Aspect=Perf", "Tense=Pqp"},
+	"plus-que-parfait": {"Aspect=Perf", "Tense=Pqp"},
+	"futur antérieur":  {"Aspect=Perf", "Tense=Fut"},
+	"actif":            {"Voice=Act"}, "passif": {"Voice=Pass"},
+	"positif": {"Degree=Pos"}, "comparatif": {"Degree=Cmp"},
+	"superlatif": {"Degree=Sup"},
+}
+
+// UDTag returns the Universal Dependencies part-of-speech tag of lemma:
+// PROPN for capitalized or "npr." nouns, X when the part of speech is
+// unknown.
+func UDTag(lemma *Lemma) string {
+	if lemma.POS == POSNoun && (strings.Contains(lemma.IndMorph, "npr.") || upperFirst(lemma.Gr) == lemma.Gr) {
+		return "PROPN"
+	}
+	if tag, ok := udTags[lemma.POS]; ok {
+		return tag
+	}
+	return "X"
+}
+
+// udFeats returns the sorted, pipe-separated UD features of the morpho
+// description desc.
+func udFeats(desc string) string {
+	var feats []string
+	eachTerm(desc, udFeatures, func(_ string, fs []string, _ bool) {
+		feats = append(feats, fs...)
+	})
+	sort.Strings(feats)
+	return strings.Join(unique(feats), "|")
+}
+
+// featureMap returns the UD features of the morpho description desc by
+// name: "Case" → "Acc".
+func featureMap(desc string) map[string]string {
+	feats := make(map[string]string)
+	eachTerm(desc, udFeatures, func(_ string, fs []string, _ bool) {
+		for _, f := range fs {
+			name, value, _ := strings.Cut(f, "=")
+			feats[name] = value
+		}
+	})
+	return feats
+}
+
+// parseUDFeatures parses a UD feature string ("Mood=Ind|Number=Sing",
+// with alternatives as in "Case=Nom,Acc") into the accepted values of
+// each feature. Features and values must be ones UDFeatures produces.
+func parseUDFeatures(feats string) (map[string][]string, error) {
+	known := make(map[string]bool)
+	for _, fs := range udFeatures {
+		for _, f := range fs {
+			known[f] = true
+		}
+	}
+	want := make(map[string][]string)
+	for _, pair := range strings.Split(feats, "|") {
+		name, values, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || values == "" {
+			return nil, fmt.Errorf("invalid UD feature %q", pair)
+		}
+		for _, v := range strings.Split(values, ",") {
+			if !known[name+"="+v] {
+				return nil, fmt.Errorf("unknown UD feature %s=%s", name, v)
+			}
+			want[name] = append(want[name], v)
+		}
+	}
+	return want, nil
+}
+
+// matchesUDFeatures tells whether the UD features of the morpho
+// description desc have one of the wanted values for each wanted feature.
+func matchesUDFeatures(desc string, want map[string][]string) bool {
+	have := make(map[string][]string)
+	eachTerm(desc, udFeatures, func(_ string, fs []string, _ bool) {
+		for _, f := range fs {
+			name, value, _ := strings.Cut(f, "=")
+			have[name] = append(have[name], value)
+		}
+	})
+	for name, values := range want {
+		found := false
+		for _, v := range values {
+			if slices.Contains(have[name], v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// inflectionByFeatures implements InflectionByFeatures.
+func (l *Lemmatizer) inflectionByFeatures(lemma *Lemma, feats string) (map[int][]string, error) {
+	want, err := parseUDFeatures(feats)
+	if err != nil {
+		return nil, err
+	}
+	table := l.inflectionTable(lemma)
+	if table == nil {
+		return nil, nil
+	}
+	cells := make(map[int][]string)
+	for mn, forms := range table.Cells {
+		if matchesUDFeatures(l.Morpho(mn), want) {
+			cells[mn] = forms
+		}
+	}
+	return cells, nil
+}
+
+// udGenders maps the gender abbreviations of the IndMorph of nouns to
+// their UD values.
+var udGenders = map[string]string{"m.": "Masc", "f.": "Fem", "n.": "Neut"}
+
+// certainFeatures implements CertainFeatures.
+func (l *Lemmatizer) certainFeatures(form string) MorphoFeatures {
+	var mf MorphoFeatures
+	mm := l.lemmatizeM(form, false)
+	first := true
+	for lemma, analyses := range mm {
+		if len(mm) == 1 {
+			mf.Lemma = lemma
+		}
+		gender := ""
+		if genders := nounGenders(lemma); lemma.POS == POSNoun && len(genders) == 1 {
+			gender = udGenders[genders[0]]
+		}
+		for _, a := range analyses {
+			feats := featureMap(l.MorphoOf(lemma, a.MorphoIndex))
+			// The morphos of nouns have no gender: their lemma has it.
+			if _, ok := feats["Gender"]; !ok && gender != "" {
+				feats["Gender"] = gender
+			}
+			if first {
+				mf.Feats, first = feats, false
+				continue
+			}
+			for name, value := range mf.Feats {
+				if feats[name] != value {
+					delete(mf.Feats, name)
+				}
+			}
+		}
+	}
+	return mf
+}
+
+// verbMood implements VerbMood.
+func (l *Lemmatizer) verbMood(form string) []MoodMatch {
+	var out []MoodMatch
+	for lemma, analyses := range l.lemmatizeRaw(strings.ToLower(form)) {
+		if lemma.POS != POSVerb {
+			continue
+		}
+		for _, a := range analyses {
+			feats := featureMap(l.MorphoOf(lemma, a.MorphoIndex))
+			m := MoodMatch{Lemma: lemma, MorphoIndex: a.MorphoIndex}
+			switch {
+			case feats["Mood"] == "Imp":
+				m.Mood, m.Number = "Imp", feats["Number"]
+				m.Person, _ = strconv.Atoi(feats["Person"])
+			case feats["VerbForm"] == "Inf":
+				m.Mood = "Inf"
+			default:
+				continue
+			}
+			if !slices.Contains(out, m) {
+				out = append(out, m)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Lemma.Key != out[j].Lemma.Key {
+			return out[i].Lemma.Key < out[j].Lemma.Key
+		}
+		return out[i].MorphoIndex < out[j].MorphoIndex
+	})
+	return out
+}
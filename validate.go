@@ -0,0 +1,83 @@
+package collatinus
+
+import (
+	"maps"
+	"slices"
+)
+
+// roundTripStride samples the lemmas whose forms validateData analyses
+// back: one in roundTripStride, in key order.
+const roundTripStride = 50
+
+// validateData implements ValidateData.
+func validateData(dataDir string) ([]LoadWarning, error) {
+	l, err := load(dataDir, true, loadConfig{})
+	if err != nil {
+		return nil, err
+	}
+	l.checkMorphoRanges()
+	l.checkRoundTrip()
+	return l.warnings, nil
+}
+
+// checkMorphoRanges warns about the morpho indices of models and
+// irregular forms that name no morpho.
+func (l *Lemmatizer) checkMorphoRanges() {
+	valid := func(mn int) bool { return mn >= 1 && mn < len(l.morphos) }
+	for _, name := range slices.Sorted(maps.Keys(l.models)) {
+		m := l.models[name]
+		var bad []int
+		for mn := range m.Desinences {
+			if !valid(mn) {
+				bad = append(bad, mn)
+			}
+		}
+		for _, mn := range m.Absents {
+			if !valid(mn) {
+				bad = append(bad, mn)
+			}
+		}
+		if len(bad) > 0 {
+			slices.Sort(bad)
+			l.warn(m.src, "model %s: morphos %v out of range 1-%d", m.Name, bad, len(l.morphos)-1)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(l.irregs)) {
+		for _, irr := range l.irregs[key] {
+			for _, mn := range irr.Morphos {
+				if !valid(mn) {
+					l.warn(irr.src, "irregular form %s: morpho %d out of range 1-%d", irr.Gr, mn, len(l.morphos)-1)
+				}
+			}
+		}
+	}
+}
+
+// checkRoundTrip inflects one lemma in roundTripStride and warns about
+// the lemmas some forms of which are not analysed back as themselves.
+func (l *Lemmatizer) checkRoundTrip() {
+	for i, key := range slices.Sorted(maps.Keys(l.lemmas)) {
+		if i%roundTripStride != 0 {
+			continue
+		}
+		lemma := l.lemmas[key]
+		table := l.inflectionTable(lemma)
+		if table == nil {
+			continue
+		}
+		var lost []int
+		for _, mn := range slices.Sorted(maps.Keys(table.Cells)) {
+			for _, form := range table.Cells[mn] {
+				if !slices.ContainsFunc(l.lemmatizeRaw(Atone(form))[lemma], func(a Analysis) bool {
+					return a.MorphoIndex == mn
+				}) {
+					lost = append(lost, mn)
+					break
+				}
+			}
+		}
+		if len(lost) > 0 {
+			l.warn(lemma.src, "lemma %s: the forms of morphos %v are not analysed back", lemma.Key, lost)
+		}
+	}
+}
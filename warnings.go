@@ -0,0 +1,53 @@
+package collatinus
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// LoadWarning describes a non-fatal problem found in the data files.
+type LoadWarning struct {
+	// File is the data file the problem was found in.
+	File string
+	// Line is the 1-based line of the offending entry (0 if unknown).
+	Line int
+	// Message describes the problem.
+	Message string
+}
+
+// String formats the warning as "file:line: message".
+func (w LoadWarning) String() string {
+	return fmt.Sprintf("%s:%d: %s", w.File, w.Line, w.Message)
+}
+
+// warn records a load warning.
+func (l *Lemmatizer) warn(src source, format string, args ...any) {
+	l.warnings = append(l.warnings, LoadWarning{
+		File:    src.file,
+		Line:    src.line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkModels warns about model cells that can never be generated. It runs
+// after the lexicon is loaded, since a radical missing from the model's
+// rules may still be given explicitly by its lemmas. It also warns about
+// the parents that are not defined before their model, and breaks the
+// circular chains of parents, which would otherwise never end.
+func (l *Lemmatizer) checkModels() {
+	for _, name := range slices.Sorted(maps.Keys(l.models)) {
+		m := l.models[name]
+		if m.parentName != "" && m.parent == nil {
+			l.warn(m.src, "model %s: parent %s is not defined before it", m.Name, m.parentName)
+		}
+		if cycle := m.parentCycle(); cycle != nil {
+			l.warn(m.src, "model %s: circular parents %s → %s", m.Name, strings.Join(cycle, " → "), m.Name)
+			m.parent = nil
+		}
+		if mns := m.UnreachableMorphos(); len(mns) > 0 {
+			l.warn(m.src, "model %s: no radical rule nor explicit radical for morphos %v", m.Name, mns)
+		}
+	}
+}